@@ -0,0 +1,194 @@
+package hjson
+
+import (
+	"strconv"
+)
+
+//Clone 深度复制v, 复制之后的值与v不共享任何容器. 容器总是以指针返回
+func Clone(v Value) Value {
+	if obj, ok := AsObject(v); ok {
+		values := make(map[string]Value, len(obj.values))
+		for key, value := range obj.values {
+			values[key] = Clone(value)
+		}
+		return &JObject{values: values}
+	}
+	if array, ok := AsArray(v); ok {
+		elements := make([]Value, len(array.elements))
+		for i, elem := range array.elements {
+			elements[i] = Clone(elem)
+		}
+		return &JArray{elements: elements}
+	}
+	return v
+}
+
+//Frozen 只读的文档快照, 可以安全地在多个goroutine之间共享.
+//修改通过With和Without得到新的快照, 只复制路径上的容器, 其余部分与原快照共享
+type Frozen struct {
+	root Value
+}
+
+//Freeze 复制v并返回它的快照, 之后修改v不会影响快照. v为nil时快照是null
+func Freeze(v Value) *Frozen {
+	return &Frozen{root: freezeValue(v)}
+}
+
+//freezeValue 复制v, nil当作JNull
+func freezeValue(v Value) Value {
+	if v == nil {
+		return JNull{}
+	}
+	return Clone(v)
+}
+
+//Type 快照根节点的种类
+func (f *Frozen) Type() JsonType {
+	return f.root.Type()
+}
+
+func (f *Frozen) String() string {
+	return f.root.String()
+}
+
+//Thaw 返回快照的可修改的深度复制
+func (f *Frozen) Thaw() Value {
+	return Clone(f.root)
+}
+
+//Scalar 根节点不是容器时返回它的值, 标量本身是不可变的
+func (f *Frozen) Scalar() (Value, bool) {
+	switch f.root.Type() {
+	case TypeObject, TypeArray:
+		return nil, false
+	}
+	return f.root, true
+}
+
+//Get 返回path处的子快照, 与f共享数据. 路径的含义与JObject.GetString相同
+func (f *Frozen) Get(path ...string) (*Frozen, error) {
+	v, err := lookup(f.root, path)
+	if err != nil {
+		return nil, err
+	}
+	return &Frozen{root: v}, nil
+}
+
+//Keys 根节点是对象时返回排序后的键
+func (f *Frozen) Keys() []string {
+	if obj, ok := AsObject(f.root); ok {
+		return obj.Keys()
+	}
+	return nil
+}
+
+//Len 根节点是对象或数组时返回其长度, 否则返回0
+func (f *Frozen) Len() int {
+	if obj, ok := AsObject(f.root); ok {
+		return obj.Len()
+	}
+	if array, ok := AsArray(f.root); ok {
+		return array.Len()
+	}
+	return 0
+}
+
+//GetString 同JObject.GetString
+func (f *Frozen) GetString(path ...string) (string, error) {
+	return f.object().GetString(path...)
+}
+
+//GetInt 同JObject.GetInt
+func (f *Frozen) GetInt(path ...string) (int64, error) {
+	return f.object().GetInt(path...)
+}
+
+//GetFloat 同JObject.GetFloat
+func (f *Frozen) GetFloat(path ...string) (float64, error) {
+	return f.object().GetFloat(path...)
+}
+
+//GetBool 同JObject.GetBool
+func (f *Frozen) GetBool(path ...string) (bool, error) {
+	return f.object().GetBool(path...)
+}
+
+//object 根节点不是对象时返回空对象, 使得所有路径都不存在
+func (f *Frozen) object() *JObject {
+	if obj, ok := AsObject(f.root); ok {
+		return obj
+	}
+	return &JObject{}
+}
+
+//With 返回把path处的值设置为v(v会被复制)之后的新快照, f本身不变.
+//路径的最后一段在对象中可以是不存在的键, 在数组中可以等于数组的长度(追加). v为nil时设置为null
+func (f *Frozen) With(v Value, path ...string) (*Frozen, error) {
+	root, err := setIn(f.root, path, 0, freezeValue(v), false)
+	if err != nil {
+		return nil, err
+	}
+	return &Frozen{root: root}, nil
+}
+
+//Without 返回删除path处的值之后的新快照, f本身不变
+func (f *Frozen) Without(path ...string) (*Frozen, error) {
+	if len(path) == 0 {
+		return nil, &PathError{Path: path, Segment: -1, Missing: true}
+	}
+	root, err := setIn(f.root, path, 0, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Frozen{root: root}, nil
+}
+
+//setIn 复制路径上的容器, 在path[i:]处设置或删除值
+func setIn(v Value, path []string, i int, nv Value, remove bool) (Value, error) {
+	if i == len(path) {
+		return nv, nil
+	}
+	seg, last := path[i], i == len(path)-1
+	if obj, ok := AsObject(v); ok {
+		child, ok := obj.values[seg]
+		if !ok && (remove || !last) {
+			return nil, &PathError{Path: path, Segment: i, Missing: true}
+		}
+		values := make(map[string]Value, len(obj.values)+1)
+		for key, value := range obj.values {
+			values[key] = value
+		}
+		if remove && last {
+			delete(values, seg)
+			return &JObject{values: values}, nil
+		}
+		child, err := setIn(child, path, i+1, nv, remove)
+		if err != nil {
+			return nil, err
+		}
+		values[seg] = child
+		return &JObject{values: values}, nil
+	}
+	if array, ok := AsArray(v); ok {
+		index, err := strconv.Atoi(seg)
+		n := len(array.elements)
+		if err != nil || index < 0 || index > n || index == n && (remove || !last) {
+			return nil, &PathError{Path: path, Segment: i, Missing: true}
+		}
+		elements := append(make([]Value, 0, n+1), array.elements...)
+		if remove && last {
+			elements = append(elements[:index], elements[index+1:]...)
+			return &JArray{elements: elements}, nil
+		}
+		if index == n {
+			elements = append(elements, nil)
+		}
+		child, err := setIn(elements[index], path, i+1, nv, remove)
+		if err != nil {
+			return nil, err
+		}
+		elements[index] = child
+		return &JArray{elements: elements}, nil
+	}
+	return nil, &PathError{Path: path, Segment: i - 1, Want: TypeObject, Got: v.Type()}
+}
@@ -0,0 +1,111 @@
+package hjson
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	value, err := ToValue([]byte(`{"a": [1, {"b": 2}], "c": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := Clone(value)
+	if !Equal(value, clone) {
+		t.Fatalf("expect clone to be equal, got:%v", clone)
+	}
+	inner, _ := clone.(*JObject).GetObject("a", "1")
+	inner.Set("b", JNumber(3))
+	clone.(*JObject).Delete("c")
+	if n, _ := value.(*JObject).GetInt("a", "1", "b"); n != 2 || !value.(*JObject).Has("c") {
+		t.Fatalf("expect original to be unchanged, got:%v", value)
+	}
+	if v := Clone(JObject{values: map[string]Value{}}); v.(*JObject).Len() != 0 {
+		t.Fatalf("expect empty object, got:%v", v)
+	}
+}
+
+func TestFrozen(t *testing.T) {
+	value, err := ToValue([]byte(`{"server": {"port": 80, "hosts": ["a", "b"]}, "debug": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen := Freeze(value)
+	value.(*JObject).Set("debug", JBool(true))
+	if debug, err := frozen.GetBool("debug"); err != nil || debug {
+		t.Fatalf("expect snapshot to be unaffected, got:%v %v", debug, err)
+	}
+
+	modified, err := frozen.With(JNumber(8080), "server", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified, err = modified.With(JString("c"), "server", "hosts", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified, err = modified.Without("debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, _ := frozen.GetInt("server", "port"); port != 80 {
+		t.Fatalf("expect original port 80, got:%d", port)
+	}
+	if port, _ := modified.GetInt("server", "port"); port != 8080 {
+		t.Fatalf("expect port 8080, got:%d", port)
+	}
+	hosts, err := modified.Get("server", "hosts")
+	if err != nil || hosts.Len() != 3 || hosts.Type() != TypeArray {
+		t.Fatalf("expect 3 hosts, got:%v %v", hosts, err)
+	}
+	if old, _ := frozen.Get("server", "hosts"); old.Len() != 2 {
+		t.Fatalf("expect original hosts to be unchanged, got:%v", old)
+	}
+	if keys := modified.Keys(); len(keys) != 1 || keys[0] != "server" {
+		t.Fatalf("unexpected keys:%v", keys)
+	}
+	if s, _ := Freeze(JString("x")).Scalar(); s != JString("x") {
+		t.Fatalf("expect scalar x, got:%v", s)
+	}
+
+	if _, err := frozen.With(JNull{}, "missing", "x"); err == nil {
+		t.Fatal("expect error setting below a missing key")
+	}
+	if _, err := frozen.Without("server", "hosts", "5"); err == nil {
+		t.Fatal("expect error removing a missing element")
+	}
+	if _, err := frozen.With(JNull{}, "debug", "x"); err == nil {
+		t.Fatal("expect error setting below a scalar")
+	}
+
+	thawed := frozen.Thaw().(*JObject)
+	thawed.Set("debug", JBool(true))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if debug, _ := frozen.GetBool("debug"); debug {
+				t.Error("expect snapshot to be unaffected by thawed copy")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFreezeNil(t *testing.T) {
+	frozen := Freeze(nil)
+	if frozen.Type() != TypeNull || frozen.String() != "null" {
+		t.Fatalf("expect null snapshot, got:%v", frozen)
+	}
+	if v, ok := frozen.Scalar(); !ok || !IsNull(v) {
+		t.Fatalf("expect null scalar, got:%v %v", v, ok)
+	}
+	modified, err := Freeze(NewObject()).With(nil, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err := modified.Get("a"); err != nil || a.Type() != TypeNull {
+		t.Fatalf("expect a to be null, got:%v %v", a, err)
+	}
+}
@@ -0,0 +1,49 @@
+//hjson-codecgen 为Go源文件中的结构体生成不使用反射的MarshalHJSON和UnmarshalHJSON方法, 用法:
+//
+//	//go:generate go run hjson/cmd/hjson-codecgen -type Config,Server
+//
+//没有指定输入文件时处理$GOFILE, 默认输出到同目录下的<file>_hjson.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"hjson"
+)
+
+func main() {
+	types := flag.String("type", "", "comma-separated list of struct types, defaults to all structs in the file")
+	output := flag.String("o", "", "output file, defaults to <file>_hjson.go")
+	flag.Parse()
+
+	file := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		file = flag.Arg(0)
+	}
+	if err := run(file, *types, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "hjson-codecgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(file, types, output string) error {
+	if file == "" {
+		return fmt.Errorf("no input file and $GOFILE is not set")
+	}
+	opts := hjson.CodecOptions{Generator: "hjson-codecgen"}
+	if types != "" {
+		opts.Types = strings.Split(types, ",")
+	}
+	src, err := hjson.GenerateCodecs(file, nil, opts)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = strings.TrimSuffix(file, ".go") + "_hjson.go"
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,79 @@
+//hjson-gostruct 根据样本Hjson/JSON文档或JSON Schema生成Go结构体, 用法:
+//
+//	//go:generate go run hjson/cmd/hjson-gostruct -type Config -package config -o config_gen.go config.hjson
+//
+//有多个输入文件时把它们都当作样本, 合并推断出的类型. 没有输入文件时从标准输入读取
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"hjson"
+)
+
+func main() {
+	typeName := flag.String("type", "Config", "name of the root type")
+	pkg := flag.String("package", "", "package name, defaults to $GOPACKAGE or main")
+	output := flag.String("o", "", "output file, defaults to stdout")
+	schema := flag.Bool("schema", false, "treat the input as a JSON Schema instead of a sample document")
+	flag.Parse()
+
+	if *pkg == "" {
+		*pkg = os.Getenv("GOPACKAGE")
+	}
+	if err := run(*typeName, *pkg, *output, *schema, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "hjson-gostruct:", err)
+		os.Exit(1)
+	}
+}
+
+func run(typeName, pkg, output string, schema bool, files []string) error {
+	var inputs [][]byte
+	if len(files) == 0 {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, data)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, data)
+	}
+	var values []hjson.Value
+	for i, data := range inputs {
+		v, err := hjson.ToValueWithOptions(data, hjson.Options{Dialect: hjson.DialectHjson})
+		if err != nil {
+			if len(files) > 0 {
+				return fmt.Errorf("%s: %v", files[i], err)
+			}
+			return err
+		}
+		values = append(values, v)
+	}
+	opts := hjson.GoStructOptions{Package: pkg, TypeName: typeName, Generator: "hjson-gostruct"}
+	var src []byte
+	var err error
+	if schema {
+		if len(values) != 1 {
+			return fmt.Errorf("-schema expects exactly one input, got %d", len(values))
+		}
+		src, err = hjson.GenerateGoStructs(values[0], opts)
+	} else {
+		src, err = hjson.GenerateGoStructsFromSamples(opts, values...)
+	}
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
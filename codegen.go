@@ -0,0 +1,493 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//CodecOptions GenerateCodecs的选项, 零值即默认行为
+type CodecOptions struct {
+	//Types 要生成方法的结构体, 默认为文件中所有的结构体
+	Types []string
+	//Generator 写在"Code generated by"注释中的生成器名字, 默认为hjson
+	Generator string
+}
+
+//GenerateCodecs 为Go源文件中的结构体生成MarshalHJSON和UnmarshalHJSON方法,
+//它们直接读写Writer和Reader, 运行时不使用反射. src为nil时读取filename.
+//字段按json标签命名, 支持omitempty和"-"; 键区分大小写, 未知的键被跳过;
+//null把指针、切片、map和interface{}置为nil, 其他字段保持不变.
+//限制和重复的键由Reader按Options检查, DuplicateCollect时后出现的值覆盖之前的值.
+//支持的字段类型: 基本类型以及以它们为底层类型的本文件中的类型, 指针, 切片, 数组,
+//键为字符串的map, interface{}, hjson.Value; 其他具名类型需要实现Marshaler和Unmarshaler
+func GenerateCodecs(filename string, src []byte, opts CodecOptions) ([]byte, error) {
+	fset := token.NewFileSet()
+	var source interface{}
+	if src != nil {
+		source = src
+	}
+	file, err := goparser.ParseFile(fset, filename, source, 0)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Generator == "" {
+		opts.Generator = "hjson"
+	}
+	g := &codegen{types: make(map[string]ast.Expr), pkg: "hjson"}
+	if file.Name.Name == "hjson" {
+		g.self = true
+	}
+	for _, imp := range file.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == "hjson" && imp.Name != nil {
+			g.pkg = imp.Name.Name
+		}
+	}
+	var structs []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			g.types[ts.Name.Name] = ts.Type
+			if _, ok := ts.Type.(*ast.StructType); ok {
+				structs = append(structs, ts.Name.Name)
+			}
+		}
+	}
+	if len(opts.Types) > 0 {
+		structs = opts.Types
+	}
+
+	fmt.Fprintf(&g.buf, "// Code generated by %s. DO NOT EDIT.\n\npackage %s\n\n", opts.Generator, file.Name.Name)
+	switch {
+	case g.self:
+	case g.pkg == "hjson":
+		g.buf.WriteString("import \"hjson\"\n\n")
+	default:
+		fmt.Fprintf(&g.buf, "import %s \"hjson\"\n\n", g.pkg)
+	}
+	for _, name := range structs {
+		st, ok := g.types[name].(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not a struct type", filename, name)
+		}
+		fields, err := g.fields(st)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", filename, name, err)
+		}
+		g.marshal(name, fields)
+		g.unmarshal(name, fields)
+		if g.err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", filename, name, g.err)
+		}
+	}
+	out, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: generated invalid code: %v", filename, err)
+	}
+	return out, nil
+}
+
+type codegen struct {
+	buf bytes.Buffer
+	//types 文件中声明的类型
+	types map[string]ast.Expr
+	//self 生成的代码在hjson包中, 引用时不加包名
+	self bool
+	//pkg 源文件中hjson包的名字
+	pkg   string
+	depth int
+	err   error
+}
+
+type codecField struct {
+	name      string
+	key       string
+	omitempty bool
+	typ       ast.Expr
+}
+
+func (g *codegen) fields(st *ast.StructType) ([]codecField, error) {
+	var fields []codecField
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("embedded field %s is not supported", types.ExprString(f.Type))
+		}
+		tag := ""
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			field := codecField{name: name.Name, key: parts[0], typ: f.Type}
+			if field.key == "" {
+				field.key = name.Name
+			}
+			for _, opt := range parts[1:] {
+				field.omitempty = field.omitempty || opt == "omitempty"
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func (g *codegen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+//tmp 返回当前嵌套层次的临时变量名
+func (g *codegen) tmp(prefix string) string {
+	return prefix + strconv.Itoa(g.depth)
+}
+
+func (g *codegen) marshal(name string, fields []codecField) {
+	g.printf("func (v *%s) MarshalHJSON(w *%sWriter) {\n", name, g.qualifier())
+	g.printf("if v == nil {\nw.Null()\nreturn\n}\n")
+	g.printf("w.BeginObject()\n")
+	for _, f := range fields {
+		expr := "v." + f.name
+		cond := ""
+		if f.omitempty {
+			cond = g.nonEmpty(expr, f.typ)
+		}
+		if cond != "" {
+			g.printf("if %s {\n", cond)
+		}
+		g.printf("w.Key(%s)\n", strconv.Quote(f.key))
+		g.encode(expr, f.typ, cond != "")
+		if cond != "" {
+			g.printf("}\n")
+		}
+	}
+	g.printf("w.EndObject()\n}\n\n")
+}
+
+func (g *codegen) unmarshal(name string, fields []codecField) {
+	g.printf("func (v *%s) UnmarshalHJSON(r *%sReader) error {\n", name, g.qualifier())
+	g.printf("if r.Null() {\nreturn nil\n}\n")
+	g.printf("if err := r.BeginObject(); err != nil {\nreturn err\n}\n")
+	g.printf("for {\nkey, more, err := r.NextKey()\nif err != nil {\nreturn err\n}\nif !more {\nreturn nil\n}\n")
+	g.printf("switch key {\n")
+	for _, f := range fields {
+		g.printf("case %s:\n", strconv.Quote(f.key))
+		g.decode("v."+f.name, f.typ, false)
+	}
+	g.printf("default:\nif err := r.Skip(); err != nil {\nreturn err\n}\n}\n}\n}\n\n")
+}
+
+//basicKinds 基本类型对应的Writer和Reader方法以及位数
+var basicKinds = map[string]struct {
+	method string
+	bits   int
+}{
+	"string": {"String", 0}, "bool": {"Bool", 0},
+	"int": {"Int", 64}, "int8": {"Int", 8}, "int16": {"Int", 16}, "int32": {"Int", 32}, "int64": {"Int", 64},
+	"uint": {"Uint", 64}, "uint8": {"Uint", 8}, "uint16": {"Uint", 16}, "uint32": {"Uint", 32}, "uint64": {"Uint", 64},
+	"uintptr": {"Uint", 64}, "byte": {"Uint", 8}, "rune": {"Int", 32},
+	"float32": {"Float", 32}, "float64": {"Float", 64},
+}
+
+//underlying 返回本文件中声明的具名类型的底层类型, 其他类型原样返回
+func (g *codegen) underlying(t ast.Expr) ast.Expr {
+	for i := 0; i < 10; i++ {
+		id, ok := t.(*ast.Ident)
+		if !ok {
+			return t
+		}
+		decl, ok := g.types[id.Name]
+		if !ok {
+			return t
+		}
+		if _, ok := decl.(*ast.StructType); ok {
+			return t
+		}
+		t = decl
+	}
+	return t
+}
+
+//isValue 判断t是否是hjson.Value
+func (g *codegen) isValue(t ast.Expr) bool {
+	switch t := t.(type) {
+	case *ast.Ident:
+		return g.self && t.Name == "Value"
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		return ok && !g.self && x.Name == g.pkg && t.Sel.Name == "Value"
+	}
+	return false
+}
+
+func isInterface(t ast.Expr) bool {
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name == "any"
+	}
+	it, ok := t.(*ast.InterfaceType)
+	return ok && len(it.Methods.List) == 0
+}
+
+//nonEmpty 返回omitempty时写入字段的条件, 结构体总是写入
+func (g *codegen) nonEmpty(expr string, t ast.Expr) string {
+	if g.isValue(t) || isInterface(t) {
+		return expr + " != nil"
+	}
+	switch u := g.underlying(t).(type) {
+	case *ast.Ident:
+		switch kind, ok := basicKinds[u.Name]; {
+		case !ok:
+			return ""
+		case kind.method == "String":
+			return expr + ` != ""`
+		case kind.method == "Bool":
+			return expr
+		}
+		return expr + " != 0"
+	case *ast.StarExpr, *ast.InterfaceType:
+		return expr + " != nil"
+	case *ast.ArrayType, *ast.MapType:
+		return "len(" + expr + ") != 0"
+	}
+	return ""
+}
+
+//encode 生成把expr写入w的语句, nonNil为true时expr已知不是nil
+func (g *codegen) encode(expr string, t ast.Expr, nonNil bool) {
+	g.depth++
+	defer func() { g.depth-- }()
+	if g.isValue(t) {
+		g.printf("w.Value(%s)\n", expr)
+		return
+	}
+	if isInterface(t) {
+		g.printf("w.Interface(%s)\n", expr)
+		return
+	}
+	typeName := types.ExprString(t)
+	nilCheck := func() {
+		if !nonNil {
+			g.printf("if %s == nil {\nw.Null()\n} else {\n", expr)
+		}
+	}
+	end := func() {
+		if !nonNil {
+			g.printf("}\n")
+		}
+	}
+	switch u := g.underlying(t).(type) {
+	case *ast.Ident:
+		kind, ok := basicKinds[u.Name]
+		if !ok {
+			g.printf("%s.MarshalHJSON(w)\n", expr)
+			return
+		}
+		expr = unparen(expr)
+		switch kind.method {
+		case "String":
+			g.printf("w.String(%s)\n", convert("string", typeName, expr))
+		case "Bool":
+			g.printf("w.Bool(%s)\n", convert("bool", typeName, expr))
+		case "Int":
+			g.printf("w.Int(%s)\n", convert("int64", typeName, expr))
+		case "Uint":
+			g.printf("w.Uint(%s)\n", convert("uint64", typeName, expr))
+		case "Float":
+			g.printf("w.Float(%s, %d)\n", convert("float64", typeName, expr), kind.bits)
+		}
+	case *ast.StarExpr:
+		nilCheck()
+		if g.hasMethods(u.X) {
+			//方法的接收者是指针, 不需要解引用
+			g.encode(expr, u.X, true)
+		} else {
+			g.encode("(*"+expr+")", u.X, true)
+		}
+		end()
+	case *ast.ArrayType:
+		if u.Len != nil {
+			nonNil = true
+		}
+		nilCheck()
+		i := g.tmp("i")
+		g.printf("w.BeginArray()\nfor %s := range %s {\n", i, expr)
+		g.encode(expr+"["+i+"]", u.Elt, false)
+		g.printf("}\nw.EndArray()\n")
+		end()
+	case *ast.MapType:
+		key, ok := g.underlying(u.Key).(*ast.Ident)
+		if !ok || key.Name != "string" {
+			g.err = fmt.Errorf("map key type %s is not supported", types.ExprString(u.Key))
+			return
+		}
+		keyType := types.ExprString(u.Key)
+		keys, k, x := g.tmp("keys"), g.tmp("k"), g.tmp("x")
+		nilCheck()
+		g.printf("%s := make([]string, 0, len(%s))\nfor %s := range %s {\n%s = append(%s, %s)\n}\n",
+			keys, expr, k, expr, keys, keys, convert("string", keyType, k))
+		g.printf("w.BeginObject()\nfor _, %s := range %sSortedKeys(%s) {\nw.Key(%s)\n%s := %s[%s]\n",
+			k, g.qualifier(), keys, k, x, expr, convert(keyType, "string", k))
+		g.encode(x, u.Value, false)
+		g.printf("}\nw.EndObject()\n")
+		end()
+	case *ast.SelectorExpr:
+		g.printf("%s.MarshalHJSON(w)\n", expr)
+	default:
+		g.err = fmt.Errorf("type %s is not supported", typeName)
+	}
+}
+
+//hasMethods 判断t是否使用生成的或用户实现的MarshalHJSON和UnmarshalHJSON
+func (g *codegen) hasMethods(t ast.Expr) bool {
+	if g.isValue(t) || isInterface(t) {
+		return false
+	}
+	switch u := g.underlying(t).(type) {
+	case *ast.Ident:
+		_, basic := basicKinds[u.Name]
+		return !basic
+	case *ast.SelectorExpr:
+		return true
+	}
+	return false
+}
+
+//convert 返回把from类型的expr转换为to类型的表达式
+func convert(to, from, expr string) string {
+	if to == from {
+		return expr
+	}
+	return to + "(" + expr + ")"
+}
+
+//unparen 去掉解引用外面多余的括号, 如"(*v.Note)"为"*v.Note"
+func unparen(expr string) string {
+	if strings.HasPrefix(expr, "(*") && strings.HasSuffix(expr, ")") && !strings.ContainsAny(expr[2:len(expr)-1], "()[]") {
+		return expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+func (g *codegen) qualifier() string {
+	if g.self {
+		return ""
+	}
+	return g.pkg + "."
+}
+
+//decode 生成从r读取target的语句, target必须可以赋值; nonNull为true时已知下一个值不是null
+func (g *codegen) decode(target string, t ast.Expr, nonNull bool) {
+	g.depth++
+	defer func() { g.depth-- }()
+	ret := "if err != nil {\nreturn err\n}\n"
+	if g.isValue(t) || isInterface(t) {
+		method := "Value"
+		if isInterface(t) {
+			method = "Interface"
+		}
+		x := g.tmp("x")
+		g.printf("{\n%s, err := r.%s()\n%s%s = %s\n}\n", x, method, ret, target, x)
+		return
+	}
+	typeName := types.ExprString(t)
+	//begin 生成null的处理: 指针、切片和map置为nil, 其他值保持不变
+	begin := func(nilable bool) {
+		switch {
+		case nonNull:
+		case nilable:
+			g.printf("if r.Null() {\n%s = nil\n} else {\n", target)
+		default:
+			g.printf("if !r.Null() {\n")
+		}
+	}
+	end := func() {
+		if !nonNull {
+			g.printf("}\n")
+		}
+	}
+	switch u := g.underlying(t).(type) {
+	case *ast.Ident:
+		kind, ok := basicKinds[u.Name]
+		if !ok {
+			g.printf("if err := %s.UnmarshalHJSON(r); err != nil {\nreturn err\n}\n", target)
+			return
+		}
+		x, from := g.tmp("x"), strings.ToLower(kind.method)
+		begin(false)
+		switch kind.method {
+		case "String", "Bool":
+			g.printf("%s, err := r.%s()\n", x, kind.method)
+		default:
+			g.printf("%s, err := r.%s(%d)\n", x, kind.method, kind.bits)
+			from += "64"
+		}
+		g.printf("%s%s = %s\n", ret, unparen(target), convert(typeName, from, x))
+		end()
+	case *ast.StarExpr:
+		begin(true)
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", target, target, types.ExprString(u.X))
+		if g.hasMethods(u.X) {
+			g.decode(target, u.X, true)
+		} else {
+			g.decode("(*"+target+")", u.X, true)
+		}
+		end()
+	case *ast.ArrayType:
+		more, x, i := g.tmp("more"), g.tmp("x"), g.tmp("i")
+		begin(u.Len == nil)
+		g.printf("if err := r.BeginArray(); err != nil {\nreturn err\n}\n")
+		if u.Len == nil {
+			g.printf("if %s == nil {\n%s = %s{}\n}\n%s = %s[:0]\n", target, target, typeName, target, target)
+		} else {
+			g.printf("%s := 0\n", i)
+		}
+		g.printf("for {\n%s, err := r.NextElement()\n%sif !%s {\nbreak\n}\n", more, ret, more)
+		if u.Len == nil {
+			g.printf("var %s %s\n", x, types.ExprString(u.Elt))
+			g.decode(x, u.Elt, false)
+			g.printf("%s = append(%s, %s)\n", target, target, x)
+		} else {
+			g.printf("if %s >= len(%s) {\nif err := r.Skip(); err != nil {\nreturn err\n}\ncontinue\n}\n", i, target)
+			g.decode(target+"["+i+"]", u.Elt, false)
+			g.printf("%s++\n", i)
+		}
+		g.printf("}\n")
+		if u.Len != nil {
+			//与encoding/json相同, 输入的数组较短时把剩余的元素置为零值
+			zero := g.tmp("zero")
+			g.printf("var %s %s\nfor ; %s < len(%s); %s++ {\n%s[%s] = %s\n}\n",
+				zero, types.ExprString(u.Elt), i, target, i, target, i, zero)
+		}
+		end()
+	case *ast.MapType:
+		k, more, x := g.tmp("k"), g.tmp("more"), g.tmp("x")
+		begin(true)
+		g.printf("if err := r.BeginObject(); err != nil {\nreturn err\n}\n")
+		g.printf("if %s == nil {\n%s = make(%s)\n}\n", target, target, typeName)
+		g.printf("for {\n%s, %s, err := r.NextKey()\n%sif !%s {\nbreak\n}\n", k, more, ret, more)
+		g.printf("var %s %s\n", x, types.ExprString(u.Value))
+		g.decode(x, u.Value, false)
+		g.printf("%s[%s] = %s\n}\n", target, convert(types.ExprString(u.Key), "string", k), x)
+		end()
+	case *ast.SelectorExpr:
+		g.printf("if err := %s.UnmarshalHJSON(r); err != nil {\nreturn err\n}\n", target)
+	default:
+		g.err = fmt.Errorf("type %s is not supported", typeName)
+	}
+}
@@ -0,0 +1,490 @@
+// Code generated by hjson-codecgen. DO NOT EDIT.
+
+package hjson
+
+func (v *codecLimits) MarshalHJSON(w *Writer) {
+	if v == nil {
+		w.Null()
+		return
+	}
+	w.BeginObject()
+	w.Key("max_conns")
+	w.Uint(uint64(v.MaxConns))
+	w.Key("timeout")
+	w.Int(int64(v.Timeout))
+	w.Key("ratio")
+	w.Float(float64(v.Ratio), 32)
+	w.EndObject()
+}
+
+func (v *codecLimits) UnmarshalHJSON(r *Reader) error {
+	if r.Null() {
+		return nil
+	}
+	if err := r.BeginObject(); err != nil {
+		return err
+	}
+	for {
+		key, more, err := r.NextKey()
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		switch key {
+		case "max_conns":
+			if !r.Null() {
+				x1, err := r.Uint(16)
+				if err != nil {
+					return err
+				}
+				v.MaxConns = uint16(x1)
+			}
+		case "timeout":
+			if !r.Null() {
+				x1, err := r.Int(32)
+				if err != nil {
+					return err
+				}
+				v.Timeout = codecSeconds(x1)
+			}
+		case "ratio":
+			if !r.Null() {
+				x1, err := r.Float(32)
+				if err != nil {
+					return err
+				}
+				v.Ratio = float32(x1)
+			}
+		default:
+			if err := r.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (v *codecServer) MarshalHJSON(w *Writer) {
+	if v == nil {
+		w.Null()
+		return
+	}
+	w.BeginObject()
+	w.Key("host")
+	w.String(v.Host)
+	w.Key("port")
+	w.Int(int64(v.Port))
+	w.Key("tls")
+	w.Bool(v.TLS)
+	w.Key("weight")
+	w.Float(v.Weight, 64)
+	w.Key("tags")
+	if v.Tags == nil {
+		w.Null()
+	} else {
+		w.BeginArray()
+		for i1 := range v.Tags {
+			w.String(v.Tags[i1])
+		}
+		w.EndArray()
+	}
+	w.Key("labels")
+	if v.Labels == nil {
+		w.Null()
+	} else {
+		keys1 := make([]string, 0, len(v.Labels))
+		for k1 := range v.Labels {
+			keys1 = append(keys1, k1)
+		}
+		w.BeginObject()
+		for _, k1 := range SortedKeys(keys1) {
+			w.Key(k1)
+			x1 := v.Labels[k1]
+			w.String(x1)
+		}
+		w.EndObject()
+	}
+	w.Key("backup")
+	if v.Backup == nil {
+		w.Null()
+	} else {
+		v.Backup.MarshalHJSON(w)
+	}
+	w.Key("limits")
+	v.Limits.MarshalHJSON(w)
+	w.EndObject()
+}
+
+func (v *codecServer) UnmarshalHJSON(r *Reader) error {
+	if r.Null() {
+		return nil
+	}
+	if err := r.BeginObject(); err != nil {
+		return err
+	}
+	for {
+		key, more, err := r.NextKey()
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		switch key {
+		case "host":
+			if !r.Null() {
+				x1, err := r.String()
+				if err != nil {
+					return err
+				}
+				v.Host = x1
+			}
+		case "port":
+			if !r.Null() {
+				x1, err := r.Int(64)
+				if err != nil {
+					return err
+				}
+				v.Port = int(x1)
+			}
+		case "tls":
+			if !r.Null() {
+				x1, err := r.Bool()
+				if err != nil {
+					return err
+				}
+				v.TLS = x1
+			}
+		case "weight":
+			if !r.Null() {
+				x1, err := r.Float(64)
+				if err != nil {
+					return err
+				}
+				v.Weight = x1
+			}
+		case "tags":
+			if r.Null() {
+				v.Tags = nil
+			} else {
+				if err := r.BeginArray(); err != nil {
+					return err
+				}
+				if v.Tags == nil {
+					v.Tags = []string{}
+				}
+				v.Tags = v.Tags[:0]
+				for {
+					more1, err := r.NextElement()
+					if err != nil {
+						return err
+					}
+					if !more1 {
+						break
+					}
+					var x1 string
+					if !r.Null() {
+						x2, err := r.String()
+						if err != nil {
+							return err
+						}
+						x1 = x2
+					}
+					v.Tags = append(v.Tags, x1)
+				}
+			}
+		case "labels":
+			if r.Null() {
+				v.Labels = nil
+			} else {
+				if err := r.BeginObject(); err != nil {
+					return err
+				}
+				if v.Labels == nil {
+					v.Labels = make(map[string]string)
+				}
+				for {
+					k1, more1, err := r.NextKey()
+					if err != nil {
+						return err
+					}
+					if !more1 {
+						break
+					}
+					var x1 string
+					if !r.Null() {
+						x2, err := r.String()
+						if err != nil {
+							return err
+						}
+						x1 = x2
+					}
+					v.Labels[k1] = x1
+				}
+			}
+		case "backup":
+			if r.Null() {
+				v.Backup = nil
+			} else {
+				if v.Backup == nil {
+					v.Backup = new(codecServer)
+				}
+				if err := v.Backup.UnmarshalHJSON(r); err != nil {
+					return err
+				}
+			}
+		case "limits":
+			if err := v.Limits.UnmarshalHJSON(r); err != nil {
+				return err
+			}
+		default:
+			if err := r.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (v *codecConfig) MarshalHJSON(w *Writer) {
+	if v == nil {
+		w.Null()
+		return
+	}
+	w.BeginObject()
+	w.Key("name")
+	w.String(v.Name)
+	w.Key("servers")
+	if v.Servers == nil {
+		w.Null()
+	} else {
+		w.BeginArray()
+		for i1 := range v.Servers {
+			v.Servers[i1].MarshalHJSON(w)
+		}
+		w.EndArray()
+	}
+	if v.Extra != nil {
+		w.Key("extra")
+		w.Interface(v.Extra)
+	}
+	if v.Raw != nil {
+		w.Key("raw")
+		w.Value(v.Raw)
+	}
+	w.Key("matrix")
+	w.BeginArray()
+	for i1 := range v.Matrix {
+		if v.Matrix[i1] == nil {
+			w.Null()
+		} else {
+			w.BeginArray()
+			for i2 := range v.Matrix[i1] {
+				w.Int(int64(v.Matrix[i1][i2]))
+			}
+			w.EndArray()
+		}
+	}
+	w.EndArray()
+	if v.Note != nil {
+		w.Key("note")
+		w.String(*v.Note)
+	}
+	if len(v.Env) != 0 {
+		w.Key("env")
+		keys1 := make([]string, 0, len(v.Env))
+		for k1 := range v.Env {
+			keys1 = append(keys1, k1)
+		}
+		w.BeginObject()
+		for _, k1 := range SortedKeys(keys1) {
+			w.Key(k1)
+			x1 := v.Env[k1]
+			w.Int(int64(x1))
+		}
+		w.EndObject()
+	}
+	w.Key("Default")
+	w.String(v.Default)
+	w.EndObject()
+}
+
+func (v *codecConfig) UnmarshalHJSON(r *Reader) error {
+	if r.Null() {
+		return nil
+	}
+	if err := r.BeginObject(); err != nil {
+		return err
+	}
+	for {
+		key, more, err := r.NextKey()
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		switch key {
+		case "name":
+			if !r.Null() {
+				x1, err := r.String()
+				if err != nil {
+					return err
+				}
+				v.Name = x1
+			}
+		case "servers":
+			if r.Null() {
+				v.Servers = nil
+			} else {
+				if err := r.BeginArray(); err != nil {
+					return err
+				}
+				if v.Servers == nil {
+					v.Servers = []codecServer{}
+				}
+				v.Servers = v.Servers[:0]
+				for {
+					more1, err := r.NextElement()
+					if err != nil {
+						return err
+					}
+					if !more1 {
+						break
+					}
+					var x1 codecServer
+					if err := x1.UnmarshalHJSON(r); err != nil {
+						return err
+					}
+					v.Servers = append(v.Servers, x1)
+				}
+			}
+		case "extra":
+			{
+				x1, err := r.Interface()
+				if err != nil {
+					return err
+				}
+				v.Extra = x1
+			}
+		case "raw":
+			{
+				x1, err := r.Value()
+				if err != nil {
+					return err
+				}
+				v.Raw = x1
+			}
+		case "matrix":
+			if !r.Null() {
+				if err := r.BeginArray(); err != nil {
+					return err
+				}
+				i1 := 0
+				for {
+					more1, err := r.NextElement()
+					if err != nil {
+						return err
+					}
+					if !more1 {
+						break
+					}
+					if i1 >= len(v.Matrix) {
+						if err := r.Skip(); err != nil {
+							return err
+						}
+						continue
+					}
+					if r.Null() {
+						v.Matrix[i1] = nil
+					} else {
+						if err := r.BeginArray(); err != nil {
+							return err
+						}
+						if v.Matrix[i1] == nil {
+							v.Matrix[i1] = []int8{}
+						}
+						v.Matrix[i1] = v.Matrix[i1][:0]
+						for {
+							more2, err := r.NextElement()
+							if err != nil {
+								return err
+							}
+							if !more2 {
+								break
+							}
+							var x2 int8
+							if !r.Null() {
+								x3, err := r.Int(8)
+								if err != nil {
+									return err
+								}
+								x2 = int8(x3)
+							}
+							v.Matrix[i1] = append(v.Matrix[i1], x2)
+						}
+					}
+					i1++
+				}
+				var zero1 []int8
+				for ; i1 < len(v.Matrix); i1++ {
+					v.Matrix[i1] = zero1
+				}
+			}
+		case "note":
+			if r.Null() {
+				v.Note = nil
+			} else {
+				if v.Note == nil {
+					v.Note = new(string)
+				}
+				x2, err := r.String()
+				if err != nil {
+					return err
+				}
+				*v.Note = x2
+			}
+		case "env":
+			if r.Null() {
+				v.Env = nil
+			} else {
+				if err := r.BeginObject(); err != nil {
+					return err
+				}
+				if v.Env == nil {
+					v.Env = make(map[string]int)
+				}
+				for {
+					k1, more1, err := r.NextKey()
+					if err != nil {
+						return err
+					}
+					if !more1 {
+						break
+					}
+					var x1 int
+					if !r.Null() {
+						x2, err := r.Int(64)
+						if err != nil {
+							return err
+						}
+						x1 = int(x2)
+					}
+					v.Env[k1] = x1
+				}
+			}
+		case "Default":
+			if !r.Null() {
+				x1, err := r.String()
+				if err != nil {
+					return err
+				}
+				v.Default = x1
+			}
+		default:
+			if err := r.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package hjson
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCodecs(t *testing.T) {
+	src, err := GenerateCodecs("codegen_types_test.go", nil, CodecOptions{Generator: "hjson-codecgen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := ioutil.ReadFile("codegen_gen_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(golden) {
+		t.Errorf("codegen_gen_test.go is out of date, run go generate")
+	}
+
+	other := "package config\n\nimport h \"hjson\"\n\ntype Config struct {\n\tRaw h.Value\n\tTags map[Name]Name `json:\"tags,omitempty\"`\n}\n\ntype Name string\n"
+	src, err = GenerateCodecs("config.go", []byte(other), CodecOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"// Code generated by hjson. DO NOT EDIT.", `import h "hjson"`,
+		"MarshalHJSON(w *h.Writer)", "UnmarshalHJSON(r *h.Reader) error", "w.Value(v.Raw)",
+		"h.SortedKeys(keys1)", "w.String(string(x1))", "v.Tags[Name(k1)] = x1"} {
+		if !strings.Contains(string(src), expect) {
+			t.Errorf("expect %q in:\n%s", expect, src)
+		}
+	}
+	if strings.Contains(string(src), "func (v *Name)") {
+		t.Errorf("only struct types should get methods")
+	}
+
+	errors := []struct {
+		src string
+		msg string
+	}{
+		{"package a\ntype A struct{ B }\ntype B struct{}", "embedded field B"},
+		{"package a\ntype A struct{ M map[int]string }", "map key type int"},
+		{"package a\ntype A struct{ F func() }", "type func() is not supported"},
+		{"package a\ntype A int", "A is not a struct type"},
+	}
+	for _, tc := range errors {
+		opts := CodecOptions{}
+		if strings.Contains(tc.src, "type A int") {
+			opts.Types = []string{"A"}
+		}
+		_, err := GenerateCodecs("a.go", []byte(tc.src), opts)
+		if err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("expect error %q, got %v", tc.msg, err)
+		}
+	}
+}
+
+func codecSample() *codecConfig {
+	note := "hello"
+	return &codecConfig{
+		Name: "web",
+		Servers: []codecServer{{
+			Host:   "a.example.com",
+			Port:   8080,
+			TLS:    true,
+			Weight: 0.5,
+			Tags:   []string{"x", "y"},
+			Labels: map[string]string{"zone": "us", "app": "web"},
+			Backup: &codecServer{Host: "b.example.com", Limits: codecLimits{Timeout: -3}},
+			Limits: codecLimits{MaxConns: 100, Timeout: 30, Ratio: 0.25},
+		}},
+		Extra:   map[string]interface{}{"k": []interface{}{true}},
+		Raw:     JString("raw"),
+		Matrix:  [2][]int8{{1, -2}},
+		Note:    &note,
+		Env:     map[string]int{"N": 1},
+		Ignored: "ignored",
+		Default: "d",
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	data, err := Marshal(codecSample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"name":"web","servers":[{"host":"a.example.com","port":8080,"tls":true,"weight":0.5,"tags":["x","y"],` +
+		`"labels":{"app":"web","zone":"us"},"backup":{"host":"b.example.com","port":0,"tls":false,"weight":0,"tags":null,` +
+		`"labels":null,"backup":null,"limits":{"max_conns":0,"timeout":-3,"ratio":0}},"limits":{"max_conns":100,"timeout":30,"ratio":0.25}}],` +
+		`"extra":{"k":[true]},"raw":"raw","matrix":[[1,-2],null],"note":"hello","env":{"N":1},"Default":"d"}`
+	if string(data) != expect {
+		t.Errorf("expect %s, got %s", expect, data)
+	}
+	if _, err := ToValue(data); err != nil {
+		t.Errorf("output is not valid JSON: %v", err)
+	}
+
+	var got codecConfig
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := codecSample()
+	want.Ignored = ""
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("expect %+v, got %+v", want, got)
+	}
+
+	//省略的字段为零值, null把指针和切片置为nil, 未知的键被跳过
+	got = codecConfig{Name: "keep", Servers: []codecServer{{}}, Note: new(string)}
+	input := "# config\nunknown: {a: [1, 2]}\nname: null\nservers: null\nnote: null\nmatrix: [[1], [2], [3]]\n"
+	if err := UnmarshalWithOptions([]byte(input), &got, Options{Dialect: DialectHjson}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "keep" || got.Servers != nil || got.Note != nil || len(got.Matrix[1]) != 1 {
+		t.Errorf("unexpected result:%+v", got)
+	}
+
+	//较短的数组把剩余的元素置为零值
+	got = codecConfig{Matrix: [2][]int8{{9}, {9}}}
+	if err := Unmarshal([]byte(`{"matrix": [[1]]}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Matrix, [2][]int8{{1}, nil}) {
+		t.Errorf("unexpected matrix:%v", got.Matrix)
+	}
+
+	errors := []struct {
+		input string
+		msg   string
+	}{
+		{`{"servers": [{"limits": {"max_conns": 70000}}]}`, "overflows uint16"},
+		{`{"servers": [{"port": "80"}]}`, "expect: number"},
+		{`{"name": "a"} {}`, "after top-level value"},
+		{`{"matrix": [[128]]}`, "overflows int8"},
+	}
+	for _, tc := range errors {
+		var c codecConfig
+		if err := Unmarshal([]byte(tc.input), &c); err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%s: expect error %q, got %v", tc.input, tc.msg, err)
+		}
+	}
+}
+
+//benchmarkServer 不含指针和omitempty, 使反射的路径(toJSONValue)可以处理
+func benchmarkServer() codecServer {
+	return codecServer{
+		Host:   "a.example.com",
+		Port:   8080,
+		TLS:    true,
+		Weight: 0.5,
+		Tags:   []string{"x", "y", "z"},
+		Labels: map[string]string{"zone": "us", "app": "web"},
+		Limits: codecLimits{MaxConns: 100, Timeout: 30, Ratio: 0.25},
+	}
+}
+
+func BenchmarkMarshalGenerated(b *testing.B) {
+	server := benchmarkServer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(&server); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalReflect(b *testing.B) {
+	server := benchmarkServer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = compactJSON(toJSONValue(server))
+	}
+}
+
+func BenchmarkUnmarshalGenerated(b *testing.B) {
+	server := benchmarkServer()
+	data, _ := Marshal(&server)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		var s codecServer
+		if err := Unmarshal(data, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalValue(b *testing.B) {
+	server := benchmarkServer()
+	data, _ := Marshal(&server)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ToValue(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
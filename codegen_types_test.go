@@ -0,0 +1,37 @@
+package hjson
+
+//go:generate go run hjson/cmd/hjson-codecgen -o codegen_gen_test.go codegen_types_test.go
+
+//以下类型供codegen_test.go使用, 对应的方法由GenerateCodecs生成在codegen_gen_test.go中
+
+type codecSeconds int32
+
+type codecLimits struct {
+	MaxConns uint16       `json:"max_conns"`
+	Timeout  codecSeconds `json:"timeout"`
+	Ratio    float32      `json:"ratio"`
+}
+
+type codecServer struct {
+	Host   string            `json:"host"`
+	Port   int               `json:"port"`
+	TLS    bool              `json:"tls"`
+	Weight float64           `json:"weight"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+	Backup *codecServer      `json:"backup"`
+	Limits codecLimits       `json:"limits"`
+}
+
+type codecConfig struct {
+	Name     string         `json:"name"`
+	Servers  []codecServer  `json:"servers"`
+	Extra    interface{}    `json:"extra,omitempty"`
+	Raw      Value          `json:"raw,omitempty"`
+	Matrix   [2][]int8      `json:"matrix"`
+	Note     *string        `json:"note,omitempty"`
+	Env      map[string]int `json:"env,omitempty"`
+	Ignored  string         `json:"-"`
+	Default  string
+	internal int
+}
@@ -0,0 +1,317 @@
+package hjson
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//Dialect 输入采用的语法, 各种语法共用同一个scanner
+type Dialect int
+
+const (
+	//DialectJSON 严格的RFC 8259: 不允许注释和多余的逗号
+	DialectJSON Dialect = iota
+	//DialectHjson 完整的Hjson: 注释(#, //, /* */), 无引号的键和字符串,
+	//'''多行字符串''', 换行代替逗号, 多余的逗号以及省略根对象的大括号
+	DialectHjson
+	//DialectJSON5 JSON5: 注释, 多余的逗号, 单引号字符串, ECMAScript标识符作为键,
+	//十六进制数字, +Infinity, NaN, 以及省略整数或小数部分的数字
+	DialectJSON5
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectJSON:
+		return "JSON"
+	case DialectHjson:
+		return "Hjson"
+	case DialectJSON5:
+		return "JSON5"
+	}
+	return "Dialect(" + strconv.Itoa(int(d)) + ")"
+}
+
+//skipComment 如果r是注释的开始, 跳过整个注释并返回true.
+//块注释中出现换行时设置newline, 未结束的块注释设置err
+func (s *scanner) skipComment(r rune) bool {
+	if s.dialect == DialectJSON {
+		return false
+	}
+	switch {
+	case r == '#' && s.dialect == DialectHjson:
+	case r == '/':
+		next, err := s.read()
+		if err != nil {
+			return false
+		}
+		if next == '*' {
+			return s.skipBlockComment()
+		}
+		if next != '/' {
+			//回退next之后让'/'重新成为最近读取的字符, 调用者还可以unread它
+			s.unread()
+			s.last = char{r: '/', size: 1}
+			s.prevLine, s.prevPos = s.line, s.pos-1
+			return false
+		}
+	default:
+		return false
+	}
+	//行注释, 换行留给nextToken处理
+	for {
+		r, err := s.read()
+		if err != nil {
+			return true
+		}
+		if r == '\n' {
+			s.unread()
+			return true
+		}
+	}
+}
+
+func (s *scanner) skipBlockComment() bool {
+	star := false
+	for {
+		r, err := s.read()
+		if err != nil {
+			s.err = s.errorf("unterminated comment")
+			return true
+		}
+		if star && r == '/' {
+			return true
+		}
+		if r == '\n' {
+			s.newline = true
+		}
+		star = r == '*'
+	}
+}
+
+//isHjsonPunct Hjson中不能出现在无引号的键里, 也不能作为无引号字符串开头的字符
+func isHjsonPunct(r rune) bool {
+	return strings.ContainsRune(",:[]{}", r)
+}
+
+//scanHjsonKey 扫描无引号的键, 键在空白或标点处结束
+func (s *scanner) scanHjsonKey() (int, string) {
+	for {
+		r, err := s.read()
+		if err != nil {
+			break
+		}
+		if s.isWhitespace(r) || r == '\n' || isHjsonPunct(r) {
+			s.unread()
+			break
+		}
+		s.write(r)
+	}
+	s.value = s.buf.String()
+	return tokenString, s.value
+}
+
+//scanQuoteless 扫描无引号的值, 值一直到行尾. 如果行首是完整的
+//数字、true、false或null, 并且之后只有逗号、结束符或注释, 则作为对应的记号
+func (s *scanner) scanQuoteless() (int, string) {
+	for {
+		r, err := s.read()
+		if err != nil {
+			break
+		}
+		if r == '\n' {
+			s.unread()
+			break
+		}
+		s.write(r)
+	}
+	raw := s.buf.String()
+	line := strings.TrimRight(raw, " \t\r")
+	if tok, n := hjsonLiteral(line); n > 0 {
+		s.pushBack(raw[n:])
+		return tok, line[:n]
+	}
+	s.value = line
+	s.trimmed = len(raw) - len(line)
+	return tokenString, line
+}
+
+//hjsonLiteral 返回line开头的字面值记号及其长度, 不是字面值时长度为0
+func hjsonLiteral(line string) (int, int) {
+	tok, n := tokenNumber, numberPrefix(line)
+	for kw, t := range map[string]int{"null": tokenNull, "true": tokenTrue, "false": tokenFalse} {
+		if strings.HasPrefix(line, kw) {
+			tok, n = t, len(kw)
+		}
+	}
+	if n == 0 {
+		return tokenInvalid, 0
+	}
+	rest := strings.TrimLeft(line[n:], " \t")
+	if rest == "" || strings.ContainsRune(",]}#", rune(rest[0])) ||
+		strings.HasPrefix(rest, "//") || strings.HasPrefix(rest, "/*") {
+		return tok, n
+	}
+	return tokenInvalid, 0
+}
+
+//numberPrefix 返回str开头符合JSON语法的数字的长度
+func numberPrefix(str string) int {
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(str) && isDigit(rune(str[i])) {
+			i++
+		}
+		return i - start
+	}
+	if i < len(str) && str[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(str) && str[i] == '0':
+		i++
+	case digits() == 0:
+		return 0
+	}
+	end := i
+	if i < len(str) && str[i] == '.' {
+		i++
+		if digits() == 0 {
+			return end
+		}
+		end = i
+	}
+	if i < len(str) && (str[i] == 'e' || str[i] == 'E') {
+		i++
+		if i < len(str) && (str[i] == '+' || str[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return end
+		}
+		end = i
+	}
+	return end
+}
+
+//scanMultiline 在读取了第一个单引号之后调用, 扫描'''多行字符串'''.
+//不是多行字符串时返回false, 此时第一个引号之后的内容都没有被读取.
+//字符串每一行开头最多去掉与开始的引号所在列数相同的空白,
+//开始引号后的第一个换行和结束引号前的最后一个换行被去掉
+func (s *scanner) scanMultiline() (int, string, bool) {
+	second, err := s.read()
+	if err != nil {
+		return 0, "", false
+	}
+	if second != '\'' {
+		s.unread()
+		return 0, "", false
+	}
+	third, err := s.read()
+	if err == nil && third != '\'' {
+		s.unread()
+	}
+	if err != nil || third != '\'' {
+		//空的单引号字符串''
+		return tokenString, "", true
+	}
+	indent := s.tokPos - 1
+	s.buf.Reset()
+	s.limitLiteral("MaxStringLength", s.limits.MaxStringLength)
+	quotes := 0
+	for quotes < 3 {
+		r, err := s.read()
+		if err != nil {
+			s.err = s.errorf("unterminated multiline string")
+			return tokenInvalid, s.buf.String(), true
+		}
+		if r == '\'' {
+			quotes++
+		} else {
+			quotes = 0
+		}
+		s.write(r)
+	}
+	raw := strings.TrimSuffix(s.buf.String(), "'''")
+	lines := strings.Split(strings.Replace(raw, "\r\n", "\n", -1), "\n")
+	if strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	} else {
+		lines[0] = strings.TrimLeft(lines[0], " \t")
+	}
+	for i, line := range lines {
+		for j := 0; j < indent && line != "" && (line[0] == ' ' || line[0] == '\t'); j++ {
+			line = line[1:]
+		}
+		lines[i] = line
+	}
+	s.value = strings.TrimSuffix(strings.Join(lines, "\n"), "\n")
+	return tokenString, raw, true
+}
+
+//scanIdentifier 扫描作为JSON5键的ECMAScript标识符
+func (s *scanner) scanIdentifier() {
+	for {
+		r, err := s.read()
+		if err != nil {
+			return
+		}
+		if !isIdentStart(r) && !isIdentPart(r) {
+			s.unread()
+			return
+		}
+		s.buf.WriteRune(r)
+	}
+}
+
+func isIdentStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+func isIdentPart(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == 0x200C || r == 0x200D
+}
+
+//bracelessRoot 判断Hjson文档是否省略了根对象的大括号, 即以"键:"开头.
+//跳过开头的空白和注释, 但不消耗其他任何字符
+func (s *scanner) bracelessRoot() bool {
+	for {
+		r, err := s.read()
+		if err != nil {
+			return false
+		}
+		if s.isWhitespace(r) || r == '\n' {
+			continue
+		}
+		if s.skipComment(r) {
+			if s.err != nil {
+				return false
+			}
+			continue
+		}
+		s.unread()
+		break
+	}
+	s.mark()
+	defer s.reset()
+	s.buf.Reset()
+	r, _ := s.read()
+	switch {
+	case r == '"' || r == '\'':
+		if s.scanString(r) != nil {
+			return false
+		}
+	case isHjsonPunct(r):
+		return false
+	default:
+		s.unread()
+		s.scanHjsonKey()
+	}
+	for {
+		r, err := s.read()
+		if err != nil || !s.isWhitespace(r) {
+			return r == ':'
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package hjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJSON5(t *testing.T) {
+	json5 := `// comment
+	{
+		unquoted: 'single \'q\'',
+		$id: 0x1F,
+		_neg: -0xff,
+		inf: +Infinity,
+		ninf: -Infinity,
+		lead: .5,
+		trail: 5.,
+		plus: +1,
+		exp: 1.5e2,
+		line: "a\
+b",
+		hex: "\x41B",
+		arr: [1, 2,],
+		/* block */ "quoted": null,
+	}`
+	expect := &JObject{
+		values: map[string]Value{
+			"unquoted": JString("single 'q'"),
+			"$id":      JNumber(31),
+			"_neg":     JNumber(-255),
+			"inf":      JFloat(math.Inf(1)),
+			"ninf":     JFloat(math.Inf(-1)),
+			"lead":     JFloat(0.5),
+			"trail":    JFloat(5),
+			"plus":     JNumber(1),
+			"exp":      JFloat(150),
+			"line":     JString("ab"),
+			"hex":      JString("AB"),
+			"arr":      &JArray{elements: []Value{JNumber(1), JNumber(2)}},
+			"quoted":   JNull{},
+		},
+	}
+	value, err := ToValueWithOptions([]byte(json5), Options{Dialect: DialectJSON5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := check(expect, value); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err = ToValueWithOptions([]byte(`[NaN, -NaN]`), Options{Dialect: DialectJSON5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range value.(*JArray).elements {
+		if f, ok := v.(JFloat); !ok || !math.IsNaN(float64(f)) {
+			t.Fatalf("expect NaN, got:%v", v)
+		}
+	}
+
+	invalid := []string{
+		`{a b: 1}`,
+		`[0x]`,
+		`[1.5.]`,
+		`["\1"]`,
+		`[Infinit]`,
+		`[1,,]`,
+		`{1: 2}`,
+	}
+	for i, j := range invalid {
+		if _, err := ToValueWithOptions([]byte(j), Options{Dialect: DialectJSON5}); err == nil {
+			t.Fatalf("case:%d expect err, got nil", i)
+		}
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	jsons := []string{
+		`// comment
+		[1]`,
+		`[1] /* comment */`,
+		`{"a":1,}`,
+		`['a']`,
+		`{a:1}`,
+		`[0x1F]`,
+		`[.5]`,
+		`[+1]`,
+		`[Infinity]`,
+		`["\x41"]`,
+		"{\"a\":1\n\"b\":2}",
+	}
+	for i, j := range jsons {
+		if _, err := ToValue([]byte(j)); err == nil {
+			t.Fatalf("case:%d expect err, got nil", i)
+		}
+	}
+}
+
+func TestHjson(t *testing.T) {
+	testCases := []struct {
+		hjson string
+		value Value
+	}{
+		{
+			hjson: "a: 1\nb: x, y]\n",
+			value: &JObject{
+				values: map[string]Value{
+					"a": JNumber(1),
+					"b": JString("x, y]"),
+				},
+			},
+		},
+		{
+			hjson: "[\n  1 # one\n  two\n  {a: 3}\n]",
+			value: &JArray{
+				elements: []Value{
+					JNumber(1),
+					JString("two"),
+					&JObject{values: map[string]Value{"a": JNumber(3)}},
+				},
+			},
+		},
+		{
+			hjson: "just a string",
+			value: JString("just a string"),
+		},
+		{
+			hjson: "# only a comment\n\"key\": value",
+			value: &JObject{values: map[string]Value{"key": JString("value")}},
+		},
+	}
+	for i, tc := range testCases {
+		value, err := ToValueWithOptions([]byte(tc.hjson), Options{Dialect: DialectHjson})
+		if err != nil {
+			t.Fatalf("case:%d %v", i, err)
+		}
+		if err := check(tc.value, value); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+	}
+}
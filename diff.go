@@ -0,0 +1,434 @@
+package hjson
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//ChangeKind 变化的种类
+type ChangeKind int
+
+const (
+	//Added b中新增的值
+	Added ChangeKind = iota
+	//Removed a中被删除的值
+	Removed
+	//Changed 值被替换
+	Changed
+	//Moved 数组元素移动了位置, 值没有变化(按键匹配时值可能也有变化, 单独报告)
+	Moved
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	case Moved:
+		return "moved"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+//Change 两个文档之间的一处变化. Path是JSON Pointer, Removed时指向a中的位置,
+//其他情况指向b中的位置; Moved时From是a中原来的位置
+type Change struct {
+	Kind ChangeKind
+	Path string
+	From string
+	Old  Value
+	New  Value
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added %s: %s", c.Path, compactJSON(c.New))
+	case Removed:
+		return fmt.Sprintf("removed %s: %s", c.Path, compactJSON(c.Old))
+	case Changed:
+		return fmt.Sprintf("changed %s: %s -> %s", c.Path, compactJSON(c.Old), compactJSON(c.New))
+	}
+	return fmt.Sprintf("moved %s -> %s", c.From, c.Path)
+}
+
+//ArrayDiffMode Diff比较数组的方式
+type ArrayDiffMode int
+
+const (
+	//ArrayOrdered 按最小编辑距离对齐元素(很大的数组去掉相同的首尾之后按位置对齐), 被删除之后又在别处出现的元素报告为Moved
+	ArrayOrdered ArrayDiffMode = iota
+	//ArraySet 把数组当作多重集合, 只报告新增和删除的元素
+	ArraySet
+	//ArrayKeyed 元素是对象时按ArrayDiff.Key字段的值匹配, 匹配的元素递归比较
+	ArrayKeyed
+)
+
+//ArrayDiff 数组的比较方式
+type ArrayDiff struct {
+	Mode ArrayDiffMode
+	//Key ArrayKeyed用来匹配元素的字段
+	Key string
+}
+
+//DiffOptions DiffWithOptions的选项
+type DiffOptions struct {
+	//Default 没有在Paths中指定的数组的比较方式
+	Default ArrayDiff
+	//Paths 按a中的JSON Pointer指定某些数组的比较方式, "*"匹配任意的键或下标, 多个模式匹配时"*"少的优先
+	Paths map[string]ArrayDiff
+}
+
+//Diff 比较a和b, 返回变化的列表. 对象的键按字典序比较; 同一个数组中新增的元素排在最后,
+//有序比较时删除和移动排在元素内部的变化之前. 数值相等的JNumber和JFloat没有变化
+func Diff(a, b Value) []Change {
+	return DiffWithOptions(a, b, DiffOptions{})
+}
+
+//DiffWithOptions 按opts比较a和b, opts.Paths中有不合法的JSON Pointer时panic
+func DiffWithOptions(a, b Value, opts DiffOptions) []Change {
+	pointers := make([]string, 0, len(opts.Paths))
+	for pointer := range opts.Paths {
+		pointers = append(pointers, pointer)
+	}
+	d := &differ{opts: opts, patterns: compilePatterns(pointers)}
+	d.diff(nil, nil, a, b)
+	return d.changes
+}
+
+type differ struct {
+	opts     DiffOptions
+	patterns []pathPattern
+	changes  []Change
+}
+
+func (d *differ) add(kind ChangeKind, path []string, old, nv Value) {
+	d.changes = append(d.changes, Change{Kind: kind, Path: FormatPointer(path), Old: old, New: nv})
+}
+
+//diff 比较a和b, apath和bpath分别是它们在各自文档中的位置
+func (d *differ) diff(apath, bpath []string, a, b Value) {
+	if EqualWithOptions(a, b, EqualOptions{NumericEqual: true}) {
+		return
+	}
+	x, xok := AsObject(a)
+	y, yok := AsObject(b)
+	if xok && yok {
+		keys := x.Keys()
+		for _, key := range y.Keys() {
+			if !x.Has(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v, inA := x.values[key]
+			w, inB := y.values[key]
+			switch {
+			case !inB:
+				d.add(Removed, append(apath, key), v, nil)
+			case !inA:
+				d.add(Added, append(bpath, key), nil, w)
+			default:
+				d.diff(append(apath, key), append(bpath, key), v, w)
+			}
+		}
+		return
+	}
+	s, sok := AsArray(a)
+	t, tok := AsArray(b)
+	if sok && tok {
+		d.diffArrays(apath, bpath, s.elements, t.elements)
+		return
+	}
+	d.add(Changed, bpath, a, b)
+}
+
+func (d *differ) mode(path []string) ArrayDiff {
+	if pointer, ok := matchPattern(d.patterns, path); ok {
+		return d.opts.Paths[pointer]
+	}
+	return d.opts.Default
+}
+
+func indexPath(path []string, i int) []string {
+	return append(path[:len(path):len(path)], strconv.Itoa(i))
+}
+
+func (d *differ) diffArrays(apath, bpath []string, a, b []Value) {
+	mode := d.mode(apath)
+	switch mode.Mode {
+	case ArraySet:
+		matched := matchSet(a, b)
+		for i, v := range a {
+			if matched[i] < 0 {
+				d.add(Removed, indexPath(apath, i), v, nil)
+			}
+		}
+		for j, w := range b {
+			if !contains(matched, j) {
+				d.add(Added, indexPath(bpath, j), nil, w)
+			}
+		}
+	case ArrayKeyed:
+		used := make([]bool, len(b))
+		matched := make([]int, len(a))
+		for i, v := range a {
+			j := -1
+			if obj, ok := AsObject(v); ok {
+				if want, ok := obj.values[mode.Key]; ok {
+					for k, w := range b {
+						if o, ok := AsObject(w); ok && !used[k] {
+							if got, ok := o.values[mode.Key]; ok && Equal(want, got) {
+								j = k
+								break
+							}
+						}
+					}
+				}
+			}
+			matched[i] = j
+			if j >= 0 {
+				used[j] = true
+			}
+		}
+		//相对顺序不变的元素(匹配下标的最长递增子序列)不算移动,
+		//这样在开头插入一个元素时其余的元素不会都被报告为移动
+		stable := increasing(matched)
+		for i, v := range a {
+			j := matched[i]
+			if j < 0 {
+				d.add(Removed, indexPath(apath, i), v, nil)
+				continue
+			}
+			if !stable[i] {
+				d.changes = append(d.changes, Change{Kind: Moved, From: FormatPointer(indexPath(apath, i)),
+					Path: FormatPointer(indexPath(bpath, j)), Old: v, New: b[j]})
+			}
+			d.diff(indexPath(apath, i), indexPath(bpath, j), v, b[j])
+		}
+		for j, w := range b {
+			if !used[j] {
+				d.add(Added, indexPath(bpath, j), nil, w)
+			}
+		}
+	default:
+		d.diffOrdered(apath, bpath, a, b)
+	}
+}
+
+//increasing 返回seq中非负的值组成的一个最长递增子序列, 结果标记seq的哪些位置在其中
+func increasing(seq []int) []bool {
+	//tails[k]是长度为k+1的递增子序列中结尾最小的一个在seq中的位置
+	var tails []int
+	prev := make([]int, len(seq))
+	for i, x := range seq {
+		if x < 0 {
+			continue
+		}
+		k := sort.Search(len(tails), func(k int) bool { return seq[tails[k]] >= x })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	in := make([]bool, len(seq))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			in[i] = true
+		}
+	}
+	return in
+}
+
+//matchSet 把a中的每个元素与b中一个相等且未被匹配的元素配对, 没有时为-1
+func matchSet(a, b []Value) []int {
+	matched := make([]int, len(a))
+	used := make([]bool, len(b))
+	for i, v := range a {
+		matched[i] = -1
+		for j, w := range b {
+			if !used[j] && EqualWithOptions(v, w, EqualOptions{NumericEqual: true}) {
+				matched[i], used[j] = j, true
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func contains(s []int, x int) bool {
+	for _, v := range s {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+//diffOrdered 按editScript对齐两个数组, 替换的元素递归比较.
+//被删除的元素如果与某个新增的元素相等, 合并为一个Moved
+func (d *differ) diffOrdered(apath, bpath []string, a, b []Value) {
+	var removed, added []int
+	type pair struct{ i, j int }
+	var changed []pair
+	//代价相同时优先删除, 这样交换位置的元素可以被识别为移动
+	i, j := 0, 0
+	for _, op := range editScript(a, b, true) {
+		switch op {
+		case editKeep:
+			i, j = i+1, j+1
+		case editRemove:
+			removed = append(removed, i)
+			i++
+		case editReplace:
+			changed = append(changed, pair{i, j})
+			i, j = i+1, j+1
+		case editInsert:
+			added = append(added, j)
+			j++
+		}
+	}
+	//删除和新增的元素中相等的配对为移动, 按哈希分组避免两两比较
+	moved := make(map[int]int)
+	movedTo := make(map[int]bool)
+	byHash := make(map[uint64][]int)
+	for _, j := range added {
+		h := Hash(b[j])
+		byHash[h] = append(byHash[h], j)
+	}
+	for _, i := range removed {
+		for _, j := range byHash[Hash(a[i])] {
+			if !movedTo[j] && EqualWithOptions(a[i], b[j], EqualOptions{NumericEqual: true}) {
+				moved[i], movedTo[j] = j, true
+				break
+			}
+		}
+	}
+	for _, i := range removed {
+		if j, ok := moved[i]; ok {
+			d.changes = append(d.changes, Change{Kind: Moved, From: FormatPointer(indexPath(apath, i)),
+				Path: FormatPointer(indexPath(bpath, j)), Old: a[i], New: b[j]})
+		} else {
+			d.add(Removed, indexPath(apath, i), a[i], nil)
+		}
+	}
+	for _, p := range changed {
+		d.diff(indexPath(apath, p.i), indexPath(bpath, p.j), a[p.i], b[p.j])
+	}
+	for _, j := range added {
+		if !movedTo[j] {
+			d.add(Added, indexPath(bpath, j), nil, b[j])
+		}
+	}
+}
+
+//RenderDiff 把变化渲染为类似unified diff的文本, 每处变化以"@@ 路径 @@"开头,
+//之后是以'-'开头的旧值和以'+'开头的新值, 对象和数组缩进显示, 对象的键按字典序排列
+func RenderDiff(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		if c.Kind == Moved {
+			fmt.Fprintf(&b, "@@ %s -> %s @@\n", c.From, c.Path)
+			writeLines(&b, " ", c.New)
+			continue
+		}
+		fmt.Fprintf(&b, "@@ %s @@\n", c.Path)
+		if c.Kind != Added {
+			writeLines(&b, "-", c.Old)
+		}
+		if c.Kind != Removed {
+			writeLines(&b, "+", c.New)
+		}
+	}
+	return b.String()
+}
+
+func writeLines(b *strings.Builder, prefix string, v Value) {
+	var buf strings.Builder
+	writeJSON(&buf, v, "  ", 0)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
+
+//compactJSON 把v编码为单行的JSON, 对象的键按字典序排列
+func compactJSON(v Value) string {
+	var b strings.Builder
+	writeJSON(&b, v, "", 0)
+	return b.String()
+}
+
+//writeJSON 把v编码为JSON, indent为空时不换行
+func writeJSON(b *strings.Builder, v Value, indent string, depth int) {
+	newline := func(depth int) {
+		if indent != "" {
+			b.WriteString("\n")
+			b.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+	sep := ","
+	colon := ":"
+	if indent != "" {
+		colon = ": "
+	}
+	if obj, ok := AsObject(v); ok {
+		b.WriteString("{")
+		for i, key := range obj.Keys() {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(depth + 1)
+			writeJSONString(b, key)
+			b.WriteString(colon)
+			writeJSON(b, obj.values[key], indent, depth+1)
+		}
+		if obj.Len() > 0 {
+			newline(depth)
+		}
+		b.WriteString("}")
+		return
+	}
+	if array, ok := AsArray(v); ok {
+		b.WriteString("[")
+		for i, elem := range array.elements {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(depth + 1)
+			writeJSON(b, elem, indent, depth+1)
+		}
+		if array.Len() > 0 {
+			newline(depth)
+		}
+		b.WriteString("]")
+		return
+	}
+	if s, ok := AsString(v); ok {
+		writeJSONString(b, s)
+		return
+	}
+	if f, ok := v.(JFloat); v == nil || ok && (math.IsNaN(float64(f)) || math.IsInf(float64(f), 0)) {
+		//JSON不能表示NaN和无穷大, 与Writer.Float相同写为null
+		b.WriteString("null")
+		return
+	}
+	b.WriteString(v.String())
+}
+
+func writeJSONString(b *strings.Builder, s string) {
+	b.Write(appendJSONString(nil, s))
+}
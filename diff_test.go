@@ -0,0 +1,159 @@
+package hjson
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := ToValue([]byte(`{"name": "web", "port": 80, "tags": ["a", "b", "c"], "env": {"DEBUG": "1"}, "replicas": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ToValue([]byte(`{"name": "web", "port": 8080, "tags": ["b", "c", "a", "d"], "env": {}, "replicas": 2.0, "image": "nginx"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		`removed /env/DEBUG: "1"`,
+		`added /image: "nginx"`,
+		`changed /port: 80 -> 8080`,
+		`moved /tags/0 -> /tags/2`,
+		`added /tags/3: "d"`,
+	}
+	var got []string
+	for _, c := range Diff(a, b) {
+		got = append(got, c.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("expect:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Fatalf("expect no changes, got:%v", changes)
+	}
+}
+
+func TestDiffArrays(t *testing.T) {
+	a, err := ToValue([]byte(`{"ids": [1, 2, 2, 3], "servers": [{"host": "a", "port": 1}, {"host": "b", "port": 2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ToValue([]byte(`{"ids": [3, 2, 1, 4], "servers": [{"host": "c", "port": 3}, {"host": "b", "port": 2}, {"host": "a", "port": 5}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DiffOptions{Paths: map[string]ArrayDiff{
+		"/ids":     {Mode: ArraySet},
+		"/servers": {Mode: ArrayKeyed, Key: "host"},
+	}}
+	expect := []string{
+		`removed /ids/2: 2`,
+		`added /ids/3: 4`,
+		`moved /servers/0 -> /servers/2`,
+		`changed /servers/2/port: 1 -> 5`,
+		`added /servers/0: {"host":"c","port":3}`,
+	}
+	var got []string
+	for _, c := range DiffWithOptions(a, b, opts) {
+		got = append(got, c.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("expect:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+
+	//在开头插入元素时, 相对顺序不变的元素不是移动
+	x, _ := ToValue([]byte(`[{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]`))
+	y, _ := ToValue([]byte(`[{"id": 0}, {"id": 1}, {"id": 2}, {"id": 4}, {"id": 3}]`))
+	got = got[:0]
+	for _, c := range DiffWithOptions(x, y, DiffOptions{Default: ArrayDiff{Mode: ArrayKeyed, Key: "id"}}) {
+		got = append(got, c.String())
+	}
+	expect = []string{`moved /2 -> /4`, `added /0: {"id":0}`}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("expect:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+
+	//多个模式匹配时"*"较少的优先, 个数相同时取字典序较小的
+	x, _ = ToValue([]byte(`{"x": {"ids": [1, 2]}}`))
+	y, _ = ToValue([]byte(`{"x": {"ids": [2, 1]}}`))
+	opts = DiffOptions{Paths: map[string]ArrayDiff{"/*/*": {}, "/x/*": {}, "/*/ids": {Mode: ArraySet}}}
+	for i := 0; i < 20; i++ {
+		if changes := DiffWithOptions(x, y, opts); len(changes) != 0 {
+			t.Fatalf("expect /*/ids to select set diff, got:%v", changes)
+		}
+	}
+
+	opts = DiffOptions{Default: ArrayDiff{Mode: ArraySet}, Paths: map[string]ArrayDiff{"/*": {}}}
+	if changes := DiffWithOptions(a, b, opts); len(changes) == 0 || changes[0].Kind != Changed || changes[0].Path != "/ids/0" {
+		t.Fatalf("expect pattern to select ordered diff, got:%v", changes)
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	a, err := ToValue([]byte(`{"limits": {"cpu": 1, "memory": "1Gi"}, "args": ["-v", "--fast"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ToValue([]byte(`{"limits": {"cpu": 2}, "args": ["--fast", "-v"], "note": "a \"quoted\"\nline"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `@@ /args/0 -> /args/1 @@
+ "-v"
+@@ /limits/cpu @@
+-1
++2
+@@ /limits/memory @@
+-"1Gi"
+@@ /note @@
++"a \"quoted\"\nline"
+`
+	if got := RenderDiff(Diff(a, b)); got != expect {
+		t.Fatalf("expect:\n%s\ngot:\n%s", expect, got)
+	}
+	expect = `@@ /limits @@
+-{
+-  "cpu": 1,
+-  "memory": "1Gi"
+-}
+`
+	if got := RenderDiff(Diff(a, NewObject())[1:]); got != expect {
+		t.Fatalf("expect:\n%s\ngot:\n%s", expect, got)
+	}
+	if got := compactJSON(&JArray{elements: []Value{JFloat(math.NaN()), JFloat(math.Inf(-1))}}); got != "[null,null]" {
+		t.Errorf("NaN and Inf should be written as null, got %s", got)
+	}
+	if got := compactJSON(JString("a\xffb\x01")); got != `"a\ufffdb\u0001"` {
+		t.Errorf("invalid UTF-8 should be replaced, got %s", got)
+	}
+}
+
+func TestDiffLargeArrays(t *testing.T) {
+	x, y := NewArray(), NewArray()
+	for i := 0; i < 3000; i++ {
+		x.Append(JNumber(i))
+		y.Append(JNumber(i + 10000))
+	}
+	//首尾相同, 中间的部分超过maxEditCells时按位置比较
+	x.Insert(0, JString("head"))
+	y.Insert(0, JString("head"))
+	x.Append(JString("tail"))
+	y.Append(JString("tail"), JNumber(0))
+	changes := Diff(x, y)
+	if len(changes) != 3001 {
+		t.Fatalf("expect 3001 changes, got %d", len(changes))
+	}
+	if c := changes[len(changes)-1]; c.Kind != Added || c.Path != "/3002" {
+		t.Errorf("expect /3002 added, got %v", c)
+	}
+}
+
+func TestDiffInvalidPattern(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*PointerError); !ok {
+			t.Errorf("expect panic with *PointerError")
+		}
+	}()
+	DiffWithOptions(NewArray(), NewArray(), DiffOptions{Paths: map[string]ArrayDiff{"~2": {Mode: ArraySet}}})
+}
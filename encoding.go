@@ -0,0 +1,166 @@
+package hjson
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//InvalidUTF8 输入中出现非法UTF-8字节时的处理方式
+type InvalidUTF8 int
+
+const (
+	//InvalidUTF8Error 报告带位置的语法错误
+	InvalidUTF8Error InvalidUTF8 = iota
+	//InvalidUTF8Replace 替换为U+FFFD
+	InvalidUTF8Replace
+	//InvalidUTF8Pass 字符串中的非法字节原样保留
+	InvalidUTF8Pass
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+)
+
+//detectEncoding 在第一次读取之前调用. 总是跳过UTF-8的BOM;
+//detect为true时根据BOM或者前4个字节中0出现的位置(RFC 4627)识别UTF-16和UTF-32,
+//并把输入转换成UTF-8. 转换之后的Offset是相对于UTF-8的
+func (s *scanner) detectEncoding(detect bool) {
+	b, _ := s.reader.Peek(4)
+	if bytes.HasPrefix(b, bomUTF8) {
+		s.reader.Discard(len(bomUTF8))
+		s.offset = len(bomUTF8)
+		return
+	}
+	if !detect {
+		return
+	}
+	t := &transcoder{}
+	switch {
+	case bytes.HasPrefix(b, bomUTF32BE):
+		t.width, t.big, t.bom = 4, true, 4
+	case bytes.HasPrefix(b, bomUTF32LE):
+		t.width, t.bom = 4, 4
+	case bytes.HasPrefix(b, bomUTF16BE):
+		t.width, t.big, t.bom = 2, true, 2
+	case bytes.HasPrefix(b, bomUTF16LE):
+		t.width, t.bom = 2, 2
+	case len(b) == 4 && b[0] == 0 && b[1] == 0 && b[2] == 0 && b[3] != 0:
+		t.width, t.big = 4, true
+	case len(b) == 4 && b[0] != 0 && b[1] == 0 && b[2] == 0 && b[3] == 0:
+		t.width = 4
+	case len(b) >= 2 && b[0] == 0 && b[1] != 0:
+		t.width, t.big = 2, true
+	case len(b) >= 2 && b[0] != 0 && b[1] == 0:
+		t.width = 2
+	default:
+		return
+	}
+	s.reader.Discard(t.bom)
+	t.r = s.reader
+	s.reader = bufio.NewReader(t)
+}
+
+//transcoder 把UTF-16或UTF-32的输入转换成UTF-8, 非法的码元转换为U+FFFD
+type transcoder struct {
+	r     *bufio.Reader
+	width int
+	big   bool
+	bom   int
+	//读取代理对时多读的一个码元
+	pending []byte
+	out     []byte
+}
+
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		r, err := t.next()
+		if err != nil {
+			return 0, err
+		}
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], r)
+		t.out = append(t.out, buf[:n]...)
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+//unit 读取一个码元, 末尾不完整的码元当作U+FFFD
+func (t *transcoder) unit() (rune, error) {
+	b := t.pending
+	t.pending = nil
+	if b == nil {
+		b = make([]byte, t.width)
+		n, err := io.ReadFull(t.r, b)
+		if err == io.ErrUnexpectedEOF || err == nil && n < t.width {
+			return utf8.RuneError, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	var u uint32
+	for i := range b {
+		if t.big {
+			u = u<<8 | uint32(b[i])
+		} else {
+			u |= uint32(b[i]) << (8 * uint(i))
+		}
+	}
+	return rune(u), nil
+}
+
+func (t *transcoder) next() (rune, error) {
+	r, err := t.unit()
+	if err != nil {
+		return 0, err
+	}
+	if t.width == 4 {
+		if !utf8.ValidRune(r) {
+			return utf8.RuneError, nil
+		}
+		return r, nil
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	//代理对的第二个码元, 不匹配时留给下一次读取
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(t.r, b); err != nil {
+		return utf8.RuneError, nil
+	}
+	t.pending = b
+	low, _ := t.unit()
+	if d := utf16.DecodeRune(r, low); d != utf8.RuneError {
+		return d, nil
+	}
+	t.pending = b
+	return utf8.RuneError, nil
+}
+
+//checkUTF8 按Options.InvalidUTF8处理读取到的非法字节
+func (s *scanner) checkUTF8(c char) {
+	if c.r != utf8.RuneError || c.size != 1 || s.invalidUTF8 != InvalidUTF8Error {
+		return
+	}
+	if s.encErr == nil {
+		s.encErr = s.errorf("invalid UTF-8 byte: %#x", c.b)
+	}
+}
+
+//write 把最近读取的字符r加入字面值, InvalidUTF8Pass时非法字节原样写入
+func (s *scanner) write(r rune) {
+	if r == utf8.RuneError && s.last.size == 1 && s.invalidUTF8 == InvalidUTF8Pass {
+		s.buf.WriteByte(s.last.b)
+		return
+	}
+	s.buf.WriteRune(r)
+}
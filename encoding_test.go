@@ -0,0 +1,57 @@
+package hjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	cases := []struct {
+		input  string
+		opts   Options
+		expect Value
+	}{
+		{"\xef\xbb\xbf[1]", Options{}, &JArray{elements: []Value{JNumber(1)}}},
+		{"[\"a\xffb\"]", Options{InvalidUTF8: InvalidUTF8Replace}, &JArray{elements: []Value{JString("a�b")}}},
+		{"[\"a\xffb\"]", Options{InvalidUTF8: InvalidUTF8Pass}, &JArray{elements: []Value{JString("a\xffb")}}},
+		{"a: x\xff\n", Options{Dialect: DialectHjson, InvalidUTF8: InvalidUTF8Pass}, &JObject{values: map[string]Value{"a": JString("x\xff")}}},
+		{"[\"\t\"]", Options{Dialect: DialectJSON5}, &JArray{elements: []Value{JString("\t")}}},
+		//UTF-16LE带BOM, 包含代理对
+		{"\xff\xfe[\x00\"\x00\x34\xd8\x1e\xdd\"\x00]\x00", Options{DetectEncoding: true}, &JArray{elements: []Value{JString("𝄞")}}},
+		{"\x00[\x00\"\x00\xe9\x00\"\x00]", Options{DetectEncoding: true}, &JArray{elements: []Value{JString("é")}}},
+		{"1\x00\x00\x00", Options{DetectEncoding: true}, JNumber(1)},
+		{"\x00\x00\x00[\x00\x00\x00]", Options{DetectEncoding: true}, &JArray{}},
+	}
+	for _, tc := range cases {
+		value, err := ToValueWithOptions([]byte(tc.input), tc.opts)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+		if err := check(tc.expect, value); err != nil {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+	}
+
+	errs := []struct {
+		input  string
+		opts   Options
+		column int
+	}{
+		{"[\"ab\xff\"]", Options{}, 5},
+		{"[1,\xe5]", Options{}, 4},
+		{"[\"a\nb\"]", Options{}, 4},
+		{"[\"a\tb\"]", Options{Dialect: DialectHjson}, 4},
+		{"[\"a\nb\"]", Options{Dialect: DialectJSON5}, 4},
+		{"\xff\xfe[\x00", Options{}, 1},
+	}
+	for _, tc := range errs {
+		_, err := ToValueWithOptions([]byte(tc.input), tc.opts)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Fatalf("%q: expect SyntaxError, got:%v", tc.input, err)
+		}
+		if syntax.Column != tc.column {
+			t.Errorf("%q: expect column %d, got:%v", tc.input, tc.column, err)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package hjson
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+//EqualOptions EqualWithOptions的选项, 零值为最严格的比较.
+//对象不保留键的顺序, 所以对象的比较总是与顺序无关
+type EqualOptions struct {
+	//NumericEqual 为true时JNumber和JFloat按数值比较, 如1和1.0相等
+	NumericEqual bool
+	//IgnoreArrayOrder 为true时把数组当作多重集合比较, 如[1,2]和[2,1]相等
+	IgnoreArrayOrder bool
+}
+
+//Equal 深度比较a和b, JNumber(1)与JFloat(1)不相等. NaN与NaN相等
+func Equal(a, b Value) bool {
+	return EqualWithOptions(a, b, EqualOptions{})
+}
+
+//EqualWithOptions 按opts深度比较a和b
+func EqualWithOptions(a, b Value, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Type() {
+	case TypeObject:
+		x, _ := AsObject(a)
+		y, _ := AsObject(b)
+		if len(x.values) != len(y.values) {
+			return false
+		}
+		for key, v := range x.values {
+			w, ok := y.values[key]
+			if !ok || !EqualWithOptions(v, w, opts) {
+				return false
+			}
+		}
+		return true
+	case TypeArray:
+		x, _ := AsArray(a)
+		y, _ := AsArray(b)
+		if len(x.elements) != len(y.elements) {
+			return false
+		}
+		if opts.IgnoreArrayOrder {
+			return equalUnordered(x.elements, y.elements, opts)
+		}
+		for i := range x.elements {
+			if !EqualWithOptions(x.elements[i], y.elements[i], opts) {
+				return false
+			}
+		}
+		return true
+	case TypeNumber:
+		if opts.NumericEqual {
+			return compareNumbers(a, b) == 0
+		}
+	}
+	return Compare(a, b) == 0
+}
+
+//equalUnordered 判断两个长度相同的数组作为多重集合是否相等
+func equalUnordered(x, y []Value, opts EqualOptions) bool {
+	used := make([]bool, len(y))
+	for _, v := range x {
+		found := false
+		for j, w := range y {
+			if !used[j] && EqualWithOptions(v, w, opts) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+//typeOrder Compare中不同种类的值的顺序
+var typeOrder = map[JsonType]int{
+	TypeNull:   0,
+	TypeBool:   1,
+	TypeNumber: 2,
+	TypeString: 3,
+	TypeArray:  4,
+	TypeObject: 5,
+}
+
+//Compare 全序比较a和b, 返回-1, 0或1. 不同种类按null < bool < number < string < array < object;
+//数字按数值比较, 数值相同时JNumber在JFloat之前, NaN在所有数字之前;
+//字符串按字节比较; 数组按元素逐个比较; 对象按排序之后的键值对逐个比较.
+//与Equal相同nil只等于nil, nil排在所有值(包括JNull)之前.
+//Compare返回0当且仅当Equal返回true
+func Compare(a, b Value) int {
+	if a == nil || b == nil {
+		switch {
+		case a == b:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+	if ta, tb := typeOrder[a.Type()], typeOrder[b.Type()]; ta != tb {
+		return compareInt(ta, tb)
+	}
+	switch a.Type() {
+	case TypeBool:
+		x, _ := AsBool(a)
+		y, _ := AsBool(b)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case TypeNumber:
+		if c := compareNumbers(a, b); c != 0 {
+			return c
+		}
+		_, x := a.(JFloat)
+		_, y := b.(JFloat)
+		if x == y {
+			return 0
+		}
+		if y {
+			return -1
+		}
+		return 1
+	case TypeString:
+		x, _ := AsString(a)
+		y, _ := AsString(b)
+		return strings.Compare(x, y)
+	case TypeArray:
+		x, _ := AsArray(a)
+		y, _ := AsArray(b)
+		for i := 0; i < len(x.elements) && i < len(y.elements); i++ {
+			if c := Compare(x.elements[i], y.elements[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(len(x.elements), len(y.elements))
+	case TypeObject:
+		x, _ := AsObject(a)
+		y, _ := AsObject(b)
+		xk, yk := x.Keys(), y.Keys()
+		for i := 0; i < len(xk) && i < len(yk); i++ {
+			if c := strings.Compare(xk[i], yk[i]); c != 0 {
+				return c
+			}
+			if c := Compare(x.values[xk[i]], y.values[yk[i]]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(len(xk), len(yk))
+	}
+	return 0
+}
+
+func compareInt(x, y int) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+//compareNumbers 精确地按数值比较两个数字, 不会因为int64转换成float64而丢失精度
+func compareNumbers(a, b Value) int {
+	switch x := a.(type) {
+	case JNumber:
+		switch y := b.(type) {
+		case JNumber:
+			if x == y {
+				return 0
+			} else if x < y {
+				return -1
+			}
+			return 1
+		case JFloat:
+			return -compareFloatInt(float64(y), int64(x))
+		}
+	case JFloat:
+		switch y := b.(type) {
+		case JNumber:
+			return compareFloatInt(float64(x), int64(y))
+		case JFloat:
+			return compareFloats(float64(x), float64(y))
+		}
+	}
+	return 0
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case math.IsNaN(x) || math.IsNaN(y):
+		if math.IsNaN(x) && math.IsNaN(y) {
+			return 0
+		} else if math.IsNaN(x) {
+			return -1
+		}
+		return 1
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareFloatInt(f float64, n int64) int {
+	switch {
+	case math.IsNaN(f) || f < math.MinInt64:
+		return -1
+	case f >= math.MaxInt64:
+		return 1
+	}
+	i := int64(f)
+	if i != n {
+		if i < n {
+			return -1
+		}
+		return 1
+	}
+	return compareFloats(f-float64(i), 0)
+}
+
+//Hash 返回v的结构哈希, 可以用于去重和缓存. 按EqualOptions{NumericEqual: true}
+//相等的值哈希相同, 对象的键的顺序不影响哈希, 数组元素的顺序影响哈希
+func Hash(v Value) uint64 {
+	h := fnv.New64a()
+	hashValue(h, v)
+	return h.Sum64()
+}
+
+func hashValue(h io.Writer, v Value) {
+	var buf [9]byte
+	if v == nil {
+		//nil与JNull不相等, 哈希也不同
+		buf[0] = 0xfe
+		h.Write(buf[:1])
+		return
+	}
+	buf[0] = byte(v.Type())
+	switch v.Type() {
+	case TypeBool:
+		if b, _ := AsBool(v); b {
+			buf[1] = 1
+		}
+		h.Write(buf[:2])
+	case TypeNumber:
+		//整数值的JFloat与JNumber哈希相同
+		if f, ok := v.(JFloat); ok {
+			if x := float64(f); x != math.Trunc(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+				bits := math.Float64bits(x)
+				if math.IsNaN(x) {
+					bits = math.Float64bits(math.NaN())
+				}
+				buf[0] = 0xff
+				binary.BigEndian.PutUint64(buf[1:], bits)
+				h.Write(buf[:])
+				return
+			}
+			v = JNumber(int64(f))
+		}
+		binary.BigEndian.PutUint64(buf[1:], uint64(v.(JNumber)))
+		h.Write(buf[:])
+	case TypeString:
+		s, _ := AsString(v)
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(s)))
+		h.Write(buf[:])
+		h.Write([]byte(s))
+	case TypeArray:
+		array, _ := AsArray(v)
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(array.elements)))
+		h.Write(buf[:])
+		for _, elem := range array.elements {
+			hashValue(h, elem)
+		}
+	case TypeObject:
+		obj, _ := AsObject(v)
+		keys := obj.Keys()
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(keys)))
+		h.Write(buf[:])
+		for _, key := range keys {
+			hashValue(h, JString(key))
+			hashValue(h, obj.values[key])
+		}
+	default:
+		h.Write(buf[:1])
+	}
+}
+
+//SortValues 按Compare的顺序排序values
+func SortValues(values []Value) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return Compare(values[i], values[j]) < 0
+	})
+}
@@ -0,0 +1,80 @@
+package hjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	parse := func(s string) Value {
+		v, err := ToValue([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	cases := []struct {
+		a, b    string
+		opts    EqualOptions
+		expect  bool
+		compare int
+	}{
+		{`{"a": [1, "x"], "b": null}`, `{"b": null, "a": [1, "x"]}`, EqualOptions{}, true, 0},
+		{`1`, `1.0`, EqualOptions{}, false, -1},
+		{`1`, `1.0`, EqualOptions{NumericEqual: true}, true, -1},
+		{`[1, 2]`, `[2, 1]`, EqualOptions{}, false, -1},
+		{`[1, 2, 2]`, `[2, 1, 2]`, EqualOptions{IgnoreArrayOrder: true}, true, -1},
+		{`[1, 1, 2]`, `[2, 2, 1]`, EqualOptions{IgnoreArrayOrder: true}, false, -1},
+		{`9007199254740993`, `9007199254740992.0`, EqualOptions{NumericEqual: true}, false, 1},
+		{`{"a": 1}`, `{"a": 1, "b": 1}`, EqualOptions{}, false, -1},
+		{`null`, `false`, EqualOptions{}, false, -1},
+		{`"b"`, `"a"`, EqualOptions{}, false, 1},
+		{`[]`, `{}`, EqualOptions{}, false, -1},
+	}
+	for _, tc := range cases {
+		a, b := parse(tc.a), parse(tc.b)
+		if got := EqualWithOptions(a, b, tc.opts); got != tc.expect {
+			t.Errorf("%s == %s: expect %v, got %v", tc.a, tc.b, tc.expect, got)
+		}
+		if got := Compare(a, b); got != tc.compare {
+			t.Errorf("compare %s %s: expect %d, got %d", tc.a, tc.b, tc.compare, got)
+		}
+		if got := Compare(b, a); got != -tc.compare {
+			t.Errorf("compare %s %s: expect %d, got %d", tc.b, tc.a, -tc.compare, got)
+		}
+		if tc.opts.NumericEqual && tc.expect && Hash(a) != Hash(b) {
+			t.Errorf("hash %s %s: expect equal hashes", tc.a, tc.b)
+		}
+	}
+
+	nan := JFloat(math.NaN())
+	if !Equal(nan, nan) || Compare(nan, JFloat(math.Inf(-1))) != -1 || Hash(nan) != Hash(JFloat(-math.NaN())) {
+		t.Fatal("expect NaN to be equal to itself and order before all numbers")
+	}
+	if Hash(parse(`{"a": [1, 2]}`)) == Hash(parse(`{"a": [2, 1]}`)) {
+		t.Fatal("expect array order to change the hash")
+	}
+	if Equal(nil, JNull{}) || !Equal(nil, nil) {
+		t.Fatal("unexpected result comparing nil")
+	}
+	if Compare(nil, nil) != 0 || Compare(nil, JNull{}) != -1 || Compare(parse(`[1]`), nil) != 1 {
+		t.Fatal("expect nil to order before all values")
+	}
+	if Hash(nil) == Hash(JNull{}) || Hash(parse(`[null]`)) == Hash(&JArray{elements: []Value{nil}}) {
+		t.Fatal("expect nil and null to hash differently")
+	}
+	withNil := []Value{JNull{}, nil, JNumber(1)}
+	SortValues(withNil)
+	if withNil[0] != nil || withNil[1] != (JNull{}) {
+		t.Fatalf("unexpected order:%v", withNil)
+	}
+
+	values := []Value{JString("a"), JNumber(2), JNull{}, parse(`{}`), JFloat(1.5), JBool(true), parse(`[]`)}
+	SortValues(values)
+	expect := []Value{JNull{}, JBool(true), JFloat(1.5), JNumber(2), JString("a"), parse(`[]`), parse(`{}`)}
+	for i := range values {
+		if !Equal(expect[i], values[i]) {
+			t.Fatalf("unexpected order:%v", values)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hjson
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Position 输入中的位置, Line和Column从1开始, Offset为字节偏移
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d column %d", p.Line, p.Column)
+}
+
+//SyntaxError 带有位置信息的语法错误. Err不为nil时是更具体的错误,
+//可以用errors.As取出
+type SyntaxError struct {
+	Msg string
+	Position
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Msg)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+//DuplicateKeyError 对象中出现了重复的键, First和Second是键两次出现的位置
+type DuplicateKeyError struct {
+	Key    string
+	First  Position
+	Second Position
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("repeated key:%s in object, first defined at %s", e.Key, e.First)
+}
+
+//LimitError 输入超出了Limits中的某项限制, 即使在错误恢复模式下也会立即中止解析
+type LimitError struct {
+	//Limit 超出的限制, 即Limits中字段的名字
+	Limit string
+	Max   int
+	Position
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: exceeded %s limit of %d", e.Position, e.Limit, e.Max)
+}
+
+//ErrorList 错误恢复模式下收集到的所有错误, 按出现顺序排列
+type ErrorList []*SyntaxError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%d errors:\n%s", len(l), strings.Join(msgs, "\n"))
+}
+
+//Err 没有错误时返回nil, 避免把空的ErrorList当作非nil的error返回
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+//IndexError 数组下标越界. Len是数组的长度, End为true表示Len本身也是有效的下标(如Insert和Slice)
+type IndexError struct {
+	Index int
+	Len   int
+	End   bool
+}
+
+func (e *IndexError) Error() string {
+	if e.End {
+		return fmt.Sprintf("index %d out of range [0, %d]", e.Index, e.Len)
+	}
+	return fmt.Sprintf("index %d out of range [0, %d)", e.Index, e.Len)
+}
@@ -0,0 +1,182 @@
+package hjson
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//PathError 按路径获取值失败. Segment是出错的路径段在Path中的下标;
+//Missing为true表示该段不存在; NotInteger为true表示该段是数字但不是int64范围内的整数;
+//否则表示该段的值类型是Got而不是Want
+type PathError struct {
+	Path       []string
+	Segment    int
+	Missing    bool
+	NotInteger bool
+	Want       JsonType
+	Got        JsonType
+}
+
+func (e *PathError) Error() string {
+	path := strings.Join(e.Path[:e.Segment+1], ".")
+	if e.Missing {
+		return fmt.Sprintf("path %s: not found", path)
+	}
+	if e.NotInteger {
+		return fmt.Sprintf("path %s: expect integer, got %s", path, e.Got)
+	}
+	return fmt.Sprintf("path %s: expect %s, got %s", path, e.Want, e.Got)
+}
+
+//lookup 沿着path查找值. 路径段在对象中是键, 在数组中是十进制的下标
+func lookup(v Value, path []string) (Value, error) {
+	for i, seg := range path {
+		if obj, ok := AsObject(v); ok {
+			next, ok := obj.values[seg]
+			if !ok {
+				return nil, &PathError{Path: path, Segment: i, Missing: true}
+			}
+			v = next
+		} else if array, ok := AsArray(v); ok {
+			index, err := strconv.Atoi(seg)
+			if err != nil || index < 0 || index >= len(array.elements) {
+				return nil, &PathError{Path: path, Segment: i, Missing: true}
+			}
+			v = array.elements[index]
+		} else {
+			return nil, &PathError{Path: path, Segment: i - 1, Want: TypeObject, Got: v.Type()}
+		}
+	}
+	return v, nil
+}
+
+//typed 查找path并检查值的类型
+func (o *JObject) typed(want JsonType, path []string) (Value, error) {
+	v, err := lookup(o, path)
+	if err != nil {
+		return nil, err
+	}
+	if v.Type() != want {
+		return nil, &PathError{Path: path, Segment: len(path) - 1, Want: want, Got: v.Type()}
+	}
+	return v, nil
+}
+
+//GetString 获取path处的字符串, 如obj.GetString("server", "tls", "cert")
+func (o *JObject) GetString(path ...string) (string, error) {
+	v, err := o.typed(TypeString, path)
+	if err != nil {
+		return "", err
+	}
+	return string(v.(JString)), nil
+}
+
+//GetInt 获取path处的整数, 没有小数部分的JFloat也可以
+func (o *JObject) GetInt(path ...string) (int64, error) {
+	v, err := o.typed(TypeNumber, path)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case JNumber:
+		return int64(n), nil
+	case JFloat:
+		if f := float64(n); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	}
+	return 0, &PathError{Path: path, Segment: len(path) - 1, NotInteger: true, Want: TypeNumber, Got: TypeNumber}
+}
+
+//GetFloat 获取path处的数字
+func (o *JObject) GetFloat(path ...string) (float64, error) {
+	v, err := o.typed(TypeNumber, path)
+	if err != nil {
+		return 0, err
+	}
+	if n, ok := v.(JNumber); ok {
+		return float64(n), nil
+	}
+	return float64(v.(JFloat)), nil
+}
+
+//GetBool 获取path处的布尔值
+func (o *JObject) GetBool(path ...string) (bool, error) {
+	v, err := o.typed(TypeBool, path)
+	if err != nil {
+		return false, err
+	}
+	return bool(v.(JBool)), nil
+}
+
+//GetObject 获取path处的对象
+func (o *JObject) GetObject(path ...string) (*JObject, error) {
+	v, err := o.typed(TypeObject, path)
+	if err != nil {
+		return nil, err
+	}
+	obj, _ := AsObject(v)
+	return obj, nil
+}
+
+//GetArray 获取path处的数组
+func (o *JObject) GetArray(path ...string) (*JArray, error) {
+	v, err := o.typed(TypeArray, path)
+	if err != nil {
+		return nil, err
+	}
+	array, _ := AsArray(v)
+	return array, nil
+}
+
+//以下...Or的变体在出错(路径不存在或类型不对)时返回def, 适合读取配置
+
+//GetStringOr 同GetString, 出错时返回def
+func (o *JObject) GetStringOr(def string, path ...string) string {
+	if v, err := o.GetString(path...); err == nil {
+		return v
+	}
+	return def
+}
+
+//GetIntOr 同GetInt, 出错时返回def
+func (o *JObject) GetIntOr(def int64, path ...string) int64 {
+	if v, err := o.GetInt(path...); err == nil {
+		return v
+	}
+	return def
+}
+
+//GetFloatOr 同GetFloat, 出错时返回def
+func (o *JObject) GetFloatOr(def float64, path ...string) float64 {
+	if v, err := o.GetFloat(path...); err == nil {
+		return v
+	}
+	return def
+}
+
+//GetBoolOr 同GetBool, 出错时返回def
+func (o *JObject) GetBoolOr(def bool, path ...string) bool {
+	if v, err := o.GetBool(path...); err == nil {
+		return v
+	}
+	return def
+}
+
+//GetObjectOr 同GetObject, 出错时返回def
+func (o *JObject) GetObjectOr(def *JObject, path ...string) *JObject {
+	if v, err := o.GetObject(path...); err == nil {
+		return v
+	}
+	return def
+}
+
+//GetArrayOr 同GetArray, 出错时返回def
+func (o *JObject) GetArrayOr(def *JArray, path ...string) *JArray {
+	if v, err := o.GetArray(path...); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,80 @@
+package hjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetter(t *testing.T) {
+	value, err := ToValue([]byte(`{
+		"server": {"tls": {"cert": "a.pem", "port": 443, "on": true}, "ratio": 0.5, "big": 1e3},
+		"hosts": ["a", {"name": "b"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := value.(*JObject)
+	if s, err := obj.GetString("server", "tls", "cert"); err != nil || s != "a.pem" {
+		t.Fatalf("expect a.pem, got:%q %v", s, err)
+	}
+	if n, err := obj.GetInt("server", "tls", "port"); err != nil || n != 443 {
+		t.Fatalf("expect 443, got:%d %v", n, err)
+	}
+	if n, err := obj.GetInt("server", "big"); err != nil || n != 1000 {
+		t.Fatalf("expect 1000, got:%d %v", n, err)
+	}
+	if _, err := obj.GetInt("server", "ratio"); err == nil {
+		t.Fatal("expect error for non-integral number")
+	} else if pathErr, ok := err.(*PathError); !ok || !pathErr.NotInteger || pathErr.Segment != 1 ||
+		err.Error() != "path server.ratio: expect integer, got number" {
+		t.Fatalf("expect PathError for non-integral number, got:%#v", err)
+	}
+	if f, err := obj.GetFloat("server", "tls", "port"); err != nil || f != 443 {
+		t.Fatalf("expect 443, got:%v %v", f, err)
+	}
+	if b, err := obj.GetBool("server", "tls", "on"); err != nil || !b {
+		t.Fatalf("expect true, got:%v %v", b, err)
+	}
+	if s, err := obj.GetString("hosts", "1", "name"); err != nil || s != "b" {
+		t.Fatalf("expect b, got:%q %v", s, err)
+	}
+	if o, err := obj.GetObject("server", "tls"); err != nil || o.Len() != 3 {
+		t.Fatalf("expect tls object, got:%v %v", o, err)
+	}
+	if a, err := obj.GetArray("hosts"); err != nil || a.Len() != 2 {
+		t.Fatalf("expect hosts array, got:%v %v", a, err)
+	}
+
+	errs := []struct {
+		path    []string
+		get     func(path ...string) error
+		segment int
+		missing bool
+		msg     string
+	}{
+		{[]string{"server", "tls", "key"}, func(p ...string) error { _, err := obj.GetString(p...); return err },
+			2, true, "path server.tls.key: not found"},
+		{[]string{"server", "tls", "cert", "x"}, func(p ...string) error { _, err := obj.GetString(p...); return err },
+			2, false, "path server.tls.cert: expect object, got string"},
+		{[]string{"server", "tls", "port"}, func(p ...string) error { _, err := obj.GetBool(p...); return err },
+			2, false, "path server.tls.port: expect bool, got number"},
+		{[]string{"hosts", "2"}, func(p ...string) error { _, err := obj.GetObject(p...); return err },
+			1, true, "path hosts.2: not found"},
+	}
+	for _, tc := range errs {
+		err := tc.get(tc.path...)
+		var pathErr *PathError
+		if !errors.As(err, &pathErr) {
+			t.Fatalf("%v: expect PathError, got:%v", tc.path, err)
+		}
+		if pathErr.Segment != tc.segment || pathErr.Missing != tc.missing || err.Error() != tc.msg {
+			t.Errorf("%v: unexpected error:%v", tc.path, err)
+		}
+	}
+
+	if obj.GetStringOr("x", "server", "name") != "x" || obj.GetIntOr(1, "server", "tls", "port") != 443 ||
+		obj.GetFloatOr(2, "server", "tls") != 2 || !obj.GetBoolOr(true, "debug") ||
+		obj.GetObjectOr(nil, "hosts") != nil || obj.GetArrayOr(nil, "hosts").Len() != 2 {
+		t.Fatal("unexpected ...Or result")
+	}
+}
@@ -0,0 +1,336 @@
+package hjson
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//GoStructOptions GenerateGoStructs的选项, 零值即默认行为
+type GoStructOptions struct {
+	//Package 生成的代码的包名, 默认为main
+	Package string
+	//TypeName 根对象的类型名, 默认为Config
+	TypeName string
+	//Generator 写在"Code generated by"注释中的生成器名字, 默认为hjson
+	Generator string
+}
+
+//GenerateGoStructsFromSamples 根据样本文档推断schema, 再生成Go结构体.
+//数组元素的类型在所有样本中合并, 不是所有样本中都出现的字段带有omitempty
+func GenerateGoStructsFromSamples(opts GoStructOptions, samples ...Value) ([]byte, error) {
+	return GenerateGoStructs(InferSchemaWithOptions(InferOptions{MaxEnum: -1, NoFormats: true}, samples...), opts)
+}
+
+//GenerateGoStructs 根据JSON Schema生成带有json标签的Go结构体, 结果经过gofmt.
+//有properties的对象生成具名的结构体, 嵌套对象的类型名是外层类型名加字段名,
+//$ref引用的schema以其名字生成一个类型; 不在required中的字段带有omitempty.
+//无法用一个Go类型表示的schema(多种类型、anyOf等)生成interface{}
+func GenerateGoStructs(schema Value, opts GoStructOptions) ([]byte, error) {
+	compiled, err := CompileSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "Config"
+	}
+	if opts.Generator == "" {
+		opts.Generator = "hjson"
+	}
+	g := &goGenerator{
+		schema: compiled,
+		names:  make(map[string]bool),
+		refs:   make(map[string]string),
+		active: make(map[string]bool),
+	}
+	//先占用根的名字, 与之同名的$defs使用Config2这样的名字; 引用"#"时得到根类型
+	name := g.uniqueName(opts.TypeName)
+	g.refs[""] = name
+	g.active[""] = true
+	root := g.goType(schema, nil, name)
+	if root != name && root != "*"+name {
+		//根不是结构体时生成类型定义
+		g.decls = append([]string{fmt.Sprintf("type %s %s\n", name, root)}, g.decls...)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by %s. DO NOT EDIT.\n\npackage %s\n\n", opts.Generator, opts.Package)
+	for _, decl := range g.decls {
+		b.WriteString(decl)
+		b.WriteString("\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+type goGenerator struct {
+	schema *Schema
+	//names 已经使用的类型名
+	names map[string]bool
+	//refs $ref目标的位置对应的类型名
+	refs map[string]string
+	//active 正在生成的$ref目标, 再次引用它们时使用指针, 避免无效的递归类型
+	active map[string]bool
+	decls  []string
+}
+
+//goType 返回path处的schema对应的Go类型, 需要新的具名类型时以name为名字
+func (g *goGenerator) goType(schema Value, path []string, name string) string {
+	if ref, ok := g.schema.refs[FormatPointer(path)]; ok {
+		return g.refType(ref)
+	}
+	obj, ok := AsObject(schema)
+	if !ok {
+		return "interface{}"
+	}
+	types := typesOf(obj)
+	nullable := false
+	if len(types) == 2 && (types[0] == "null" || types[1] == "null") {
+		nullable = true
+		if types[0] == "null" {
+			types = types[1:]
+		} else {
+			types = types[:1]
+		}
+	}
+	if len(types) == 2 && types[0] == "integer" && types[1] == "number" {
+		types = []string{"number"}
+	}
+	if len(types) != 1 {
+		return "interface{}"
+	}
+	var t string
+	switch types[0] {
+	case "string":
+		t = "string"
+	case "integer":
+		t = "int64"
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		item, ok := obj.values["items"]
+		if _, tuple := AsArray(item); !ok || tuple {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(item, childPath(path, "items"), name+"Item")
+	case "object":
+		if props, ok := AsObject(obj.values["properties"]); ok && props.Len() > 0 {
+			t = "*" + g.structType(obj, props, path, name)
+			if !nullable {
+				t = t[1:]
+			}
+			return t
+		}
+		if sub, ok := obj.values["additionalProperties"]; ok {
+			if _, isBool := sub.(JBool); !isBool {
+				return "map[string]" + g.goType(sub, childPath(path, "additionalProperties"), name+"Value")
+			}
+		}
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+	if nullable {
+		return "*" + t
+	}
+	return t
+}
+
+//typesOf 返回schema的类型列表, 没有type时根据其他关键字推断
+func typesOf(obj *JObject) []string {
+	t, ok := obj.values["type"]
+	if !ok {
+		switch {
+		case obj.Has("properties") || obj.Has("additionalProperties"):
+			return []string{"object"}
+		case obj.Has("items") || obj.Has("prefixItems"):
+			return []string{"array"}
+		}
+		for _, keyword := range []string{"const", "enum"} {
+			values := []Value{obj.values[keyword]}
+			if array, ok := AsArray(values[0]); ok && keyword == "enum" {
+				values = array.elements
+			}
+			if len(values) == 0 || values[0] == nil {
+				continue
+			}
+			set := make(map[string]bool)
+			for _, v := range values {
+				name := schemaTypeOf(v)
+				if v.Type() == TypeNumber && isSchemaType(v, "integer") {
+					name = "integer"
+				}
+				set[name] = true
+			}
+			var types []string
+			for _, name := range schemaTypeOrder {
+				if set[name] {
+					types = append(types, name)
+				}
+			}
+			return types
+		}
+		return nil
+	}
+	if s, ok := AsString(t); ok {
+		return []string{s}
+	}
+	var types []string
+	array, _ := AsArray(t)
+	set := make(map[string]bool)
+	for _, elem := range array.elements {
+		s, _ := AsString(elem)
+		set[s] = true
+	}
+	for _, name := range schemaTypeOrder {
+		if set[name] {
+			types = append(types, name)
+		}
+	}
+	return types
+}
+
+//refType 为$ref的目标生成一个类型, 类型名取自目标位置的最后一段
+func (g *goGenerator) refType(ref schemaRef) string {
+	pointer := FormatPointer(ref.path)
+	if name, ok := g.refs[pointer]; ok {
+		if g.active[pointer] {
+			return "*" + name
+		}
+		return name
+	}
+	name := "Ref"
+	if len(ref.path) > 0 {
+		name = goName(ref.path[len(ref.path)-1])
+	}
+	//先占位, 递归的引用会得到同一个名字
+	name = g.uniqueName(name)
+	g.refs[pointer] = name
+	g.active[pointer] = true
+	t := g.goType(ref.schema, ref.path, name)
+	delete(g.active, pointer)
+	if t != name && t != "*"+name {
+		g.decls = append(g.decls, fmt.Sprintf("type %s %s\n", name, t))
+	}
+	return name
+}
+
+func (g *goGenerator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+//structType 生成结构体类型的声明并返回类型名
+func (g *goGenerator) structType(obj, props *JObject, path []string, name string) string {
+	if _, ok := g.refs[FormatPointer(path)]; !ok {
+		name = g.uniqueName(name)
+	}
+	required := make(map[string]bool)
+	if list, err := stringList(obj.values["required"]); err == nil {
+		for _, key := range list {
+			required[key] = true
+		}
+	}
+	//先占住声明的位置, 使外层类型排在嵌套类型之前
+	index := len(g.decls)
+	g.decls = append(g.decls, "")
+	var b strings.Builder
+	writeGoComment(&b, obj, "", name)
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := make(map[string]bool)
+	keys := props.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := goName(key)
+		for i := 2; fields[field]; i++ {
+			field = goName(key) + strconv.Itoa(i)
+		}
+		fields[field] = true
+		sub := props.values[key]
+		t := g.goType(sub, childPath(path, "properties", key), name+field)
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+		}
+		if subObj, ok := AsObject(sub); ok {
+			writeGoComment(&b, subObj, "\t", "")
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%s`\n", field, t, strconv.Quote(tag))
+	}
+	b.WriteString("}\n")
+	g.decls[index] = b.String()
+	return name
+}
+
+//writeGoComment 把schema的description写成注释
+func writeGoComment(b *strings.Builder, obj *JObject, indent, name string) {
+	desc, ok := AsString(obj.values["description"])
+	if !ok || desc == "" {
+		return
+	}
+	if name != "" {
+		desc = name + " " + desc
+	}
+	for _, line := range strings.Split(desc, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+//goInitialisms 生成字段名时全部大写的缩写
+var goInitialisms = map[string]bool{"ID": true, "URL": true, "URI": true, "HTTP": true, "HTTPS": true,
+	"API": true, "JSON": true, "IP": true, "TLS": true, "CPU": true, "UUID": true, "DNS": true, "TTL": true}
+
+//goName 把键转换为导出的Go标识符, 如"max_conns"为MaxConns, "user-id"为UserID
+func goName(key string) string {
+	var words []string
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		rs := []rune(w)
+		b.WriteRune(unicode.ToUpper(rs[0]))
+		b.WriteString(string(rs[1:]))
+	}
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
@@ -0,0 +1,153 @@
+package hjson
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGoStructs(t *testing.T) {
+	schema, err := ToValue([]byte(`{
+	  "description": "describes a service.",
+	  "type": "object",
+	  "required": ["name", "listen"],
+	  "properties": {
+	    "name": {"type": "string", "description": "unique service name"},
+	    "listen": {"$ref": "#/$defs/address"},
+	    "upstreams": {"type": "array", "items": {"$ref": "#/$defs/address"}},
+	    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+	    "mode": {"enum": ["dev", "prod"]},
+	    "timeout": {"type": ["number", "null"]},
+	    "tls": {"type": ["object", "null"], "properties": {"cert_file": {"type": "string"}}},
+	    "extra": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+	    "2fa": {"type": "boolean"}
+	  },
+	  "$defs": {
+	    "address": {"type": "object", "required": ["host"], "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}}
+	  }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := GenerateGoStructs(schema, GoStructOptions{Package: "config", TypeName: "Service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "// Code generated by hjson. DO NOT EDIT.\n\npackage config\n\n" +
+		"// Service describes a service.\n" +
+		"type Service struct {\n" +
+		"\tX2fa   bool              `json:\"2fa,omitempty\"`\n" +
+		"\tExtra  interface{}       `json:\"extra,omitempty\"`\n" +
+		"\tLabels map[string]string `json:\"labels,omitempty\"`\n" +
+		"\tListen Address           `json:\"listen\"`\n" +
+		"\tMode   string            `json:\"mode,omitempty\"`\n" +
+		"\t// unique service name\n" +
+		"\tName      string      `json:\"name\"`\n" +
+		"\tTimeout   *float64    `json:\"timeout,omitempty\"`\n" +
+		"\tTLS       *ServiceTLS `json:\"tls,omitempty\"`\n" +
+		"\tUpstreams []Address   `json:\"upstreams,omitempty\"`\n" +
+		"}\n\n" +
+		"type Address struct {\n" +
+		"\tHost string `json:\"host\"`\n" +
+		"\tPort int64  `json:\"port,omitempty\"`\n" +
+		"}\n\n" +
+		"type ServiceTLS struct {\n" +
+		"\tCertFile string `json:\"cert_file,omitempty\"`\n" +
+		"}\n"
+	if string(src) != expect {
+		t.Fatalf("expect:\n%s\ngot:\n%s", expect, src)
+	}
+
+	if _, err := GenerateGoStructs(JNumber(1), GoStructOptions{}); err == nil {
+		t.Fatalf("expect invalid schema error")
+	}
+}
+
+func TestGenerateGoStructsFromSamples(t *testing.T) {
+	var samples []Value
+	for _, input := range []string{
+		`{"hosts": [{"name": "a", "port": 80}], "retries": 1}`,
+		`{"hosts": [{"name": "b", "weight": 0.5}], "retries": 1.5, "debug": true}`,
+		`[1, 2]`,
+	} {
+		v, err := ToValue([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, v)
+	}
+	src, err := GenerateGoStructsFromSamples(GoStructOptions{}, samples[:2]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "// Code generated by hjson. DO NOT EDIT.\n\npackage main\n\n" +
+		"type Config struct {\n" +
+		"\tDebug   bool              `json:\"debug,omitempty\"`\n" +
+		"\tHosts   []ConfigHostsItem `json:\"hosts\"`\n" +
+		"\tRetries float64           `json:\"retries\"`\n" +
+		"}\n\n" +
+		"type ConfigHostsItem struct {\n" +
+		"\tName   string  `json:\"name\"`\n" +
+		"\tPort   int64   `json:\"port,omitempty\"`\n" +
+		"\tWeight float64 `json:\"weight,omitempty\"`\n" +
+		"}\n"
+	if string(src) != expect {
+		t.Fatalf("expect:\n%s\ngot:\n%s", expect, src)
+	}
+	src, err = GenerateGoStructsFromSamples(GoStructOptions{TypeName: "Ports"}, samples[2])
+	if err != nil || string(src) != "// Code generated by hjson. DO NOT EDIT.\n\npackage main\n\ntype Ports []int64\n" {
+		t.Fatalf("unexpected source:%s %v", src, err)
+	}
+}
+
+//typeCheck 用go/types检查生成的代码, format.Source只检查语法
+func typeCheck(t *testing.T, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "gen.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&types.Config{}).Check("config", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("%v in:\n%s", err, src)
+	}
+}
+
+func TestGenerateGoStructsRecursive(t *testing.T) {
+	cases := []struct {
+		schema  string
+		structs int
+		expect  []string
+	}{
+		{`{"type": "object", "properties": {"next": {"$ref": "#"}, "value": {"type": "integer"}}}`, 1,
+			[]string{"type Config struct", "Next  *Config"}},
+		{`{"type": "object", "properties": {"root": {"$ref": "#/$defs/node"}},
+		  "$defs": {"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}},
+		    "parent": {"$ref": "#/$defs/node"}}}}}`, 2,
+			[]string{"Root Node", "Children []*Node", "Parent   *Node"}},
+		{`{"type": "array", "items": {"$ref": "#/$defs/Config"}, "$defs": {"Config": {"type": "object", "properties": {"a": {"type": "string"}}}}}`, 1,
+			[]string{"type Config []Config2", "type Config2 struct"}},
+	}
+	for _, tc := range cases {
+		schema, err := ToValue([]byte(tc.schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := GenerateGoStructs(schema, GoStructOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		typeCheck(t, src)
+		for _, expect := range tc.expect {
+			if !strings.Contains(string(src), expect) {
+				t.Errorf("expect %q in:\n%s", expect, src)
+			}
+		}
+		if n := strings.Count(string(src), "struct {"); n != tc.structs {
+			t.Errorf("expect %d structs, got %d in:\n%s", tc.structs, n, src)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package hjson
+
+import (
+	"sort"
+)
+
+//InferOptions InferSchemaWithOptions的选项, 零值即默认行为
+type InferOptions struct {
+	//MaxEnum 字符串最多有几种不同的取值时推断为enum, 0表示5, 负数表示不推断enum.
+	//只有某个取值出现过不止一次时才推断, 避免把每个样本都不同的字段当作enum
+	MaxEnum int
+	//NoFormats 为true时不推断format
+	NoFormats bool
+}
+
+//inferFormats 推断时尝试的格式, 按优先级排列. hostname之类能匹配普通单词的格式不在其中
+var inferFormats = []string{"date-time", "date", "time", "duration", "uuid", "email", "ipv4", "ipv6", "uri"}
+
+//InferSchema 根据样本推断描述它们的JSON Schema(2020-12), nil当作null
+func InferSchema(samples ...Value) Value {
+	return InferSchemaWithOptions(InferOptions{}, samples...)
+}
+
+//InferSchemaWithOptions 按opts根据样本推断JSON Schema. 同一位置的不同类型合并为类型列表,
+//同时出现整数和小数时为number; 对象的字段只有在所有样本中都出现时才是required;
+//所有取值都符合某种格式的字符串标记format
+func InferSchemaWithOptions(opts InferOptions, samples ...Value) Value {
+	if opts.MaxEnum == 0 {
+		opts.MaxEnum = 5
+	}
+	s := newShape()
+	for _, sample := range samples {
+		s.add(sample, opts)
+	}
+	schema := s.schema(opts)
+	schema.Set("$schema", JString("https://json-schema.org/draft/2020-12/schema"))
+	return schema
+}
+
+//shape 同一位置上所有取值的汇总
+type shape struct {
+	types map[string]bool
+	//strings 出现过的字符串及次数, 超过MaxEnum种之后不再记录
+	strings  map[string]int
+	distinct int
+	repeated bool
+	//formats 到目前为止所有字符串都符合的格式, nil表示还没有字符串
+	formats map[string]bool
+	//seen 作为对象的字段出现的次数, objects 是对象出现的次数
+	seen    int
+	objects int
+	props   map[string]*shape
+	items   *shape
+}
+
+func newShape() *shape {
+	return &shape{types: make(map[string]bool), strings: make(map[string]int)}
+}
+
+func (s *shape) add(v Value, opts InferOptions) {
+	switch v := v.(type) {
+	case JNumber:
+		s.types["integer"] = true
+	case JFloat:
+		s.types["number"] = true
+	case JString:
+		s.types["string"] = true
+		s.addString(string(v), opts)
+	default:
+		if obj, ok := AsObject(v); ok {
+			s.types["object"] = true
+			s.objects++
+			if s.props == nil {
+				s.props = make(map[string]*shape)
+			}
+			obj.Range(func(key string, value Value) bool {
+				prop, ok := s.props[key]
+				if !ok {
+					prop = newShape()
+					s.props[key] = prop
+				}
+				prop.add(value, opts)
+				prop.seen++
+				return true
+			})
+		} else if array, ok := AsArray(v); ok {
+			s.types["array"] = true
+			if s.items == nil && array.Len() > 0 {
+				s.items = newShape()
+			}
+			for _, elem := range array.elements {
+				s.items.add(elem, opts)
+			}
+		} else {
+			s.types[schemaTypeOf(v)] = true
+		}
+	}
+}
+
+func (s *shape) addString(str string, opts InferOptions) {
+	if n, ok := s.strings[str]; ok {
+		s.strings[str] = n + 1
+		s.repeated = true
+	} else if s.distinct <= opts.MaxEnum {
+		s.strings[str] = 1
+		s.distinct++
+	}
+	if opts.NoFormats {
+		return
+	}
+	if s.formats == nil {
+		s.formats = make(map[string]bool)
+		for _, format := range inferFormats {
+			s.formats[format] = true
+		}
+	}
+	for format := range s.formats {
+		if !formats[format](str) || format == "uri" && !isURL(str) {
+			delete(s.formats, format)
+		}
+	}
+}
+
+//isURL uri格式允许"a:b"这样的值, 推断时要求有"://"
+func isURL(s string) bool {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] == ':' {
+			return s[i+1] == '/' && s[i+2] == '/' && i > 0
+		}
+	}
+	return false
+}
+
+//schemaTypeOrder schema中type列表的顺序
+var schemaTypeOrder = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+func (s *shape) schema(opts InferOptions) *JObject {
+	schema := NewObject()
+	var types []string
+	for _, t := range schemaTypeOrder {
+		if s.types[t] && !(t == "integer" && s.types["number"]) {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+		return schema
+	case 1:
+		schema.Set("type", JString(types[0]))
+	default:
+		list := NewArray()
+		for _, t := range types {
+			list.Append(JString(t))
+		}
+		schema.Set("type", list)
+	}
+	if s.types["string"] {
+		//只有字符串和null时才能用enum, 否则会排除其他类型的值
+		onlyStrings := len(types) == 1 || len(types) == 2 && s.types["null"]
+		if onlyStrings && s.distinct <= opts.MaxEnum && s.repeated {
+			var values []string
+			for str := range s.strings {
+				values = append(values, str)
+			}
+			sort.Strings(values)
+			enum := NewArray()
+			for _, str := range values {
+				enum.Append(JString(str))
+			}
+			if s.types["null"] {
+				enum.Append(JNull{})
+			}
+			schema.Set("enum", enum)
+		} else if format := s.format(); format != "" {
+			schema.Set("format", JString(format))
+		}
+	}
+	if s.types["object"] {
+		properties := NewObject()
+		required := NewArray()
+		keys := make([]string, 0, len(s.props))
+		for key := range s.props {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop := s.props[key]
+			properties.Set(key, prop.schema(opts))
+			if prop.seen == s.objects {
+				required.Append(JString(key))
+			}
+		}
+		schema.Set("properties", properties)
+		if required.Len() > 0 {
+			schema.Set("required", required)
+		}
+	}
+	if s.items != nil {
+		schema.Set("items", s.items.schema(opts))
+	}
+	return schema
+}
+
+func (s *shape) format() string {
+	for _, format := range inferFormats {
+		if s.formats[format] {
+			return format
+		}
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	inputs := []string{`
+		name: web
+		env: prod
+		port: 80
+		ratio: 1
+		created: 2024-01-02T03:04:05Z
+		home: https://a.example.com
+		tags: ["a", "b"]
+		limits: {cpu: 1}
+		`, `
+		name: api
+		env: dev
+		port: 8080
+		ratio: 0.5
+		created: 2024-02-03T04:05:06+08:00
+		home: https://b.example.com
+		tags: []
+		owner: null
+		`, `
+		name: db
+		env: prod
+		port: 5432
+		ratio: 2
+		created: 2024-03-04T05:06:07Z
+		home: https://c.example.com
+		tags: ["c", 1]
+		owner: ops@example.com
+		`,
+	}
+	var samples []Value
+	for _, input := range inputs {
+		v, err := ToValueWithOptions([]byte(input), Options{Dialect: DialectHjson})
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, v)
+	}
+	expect, err := ToValue([]byte(`{
+	  "$schema": "https://json-schema.org/draft/2020-12/schema",
+	  "type": "object",
+	  "properties": {
+	    "created": {"type": "string", "format": "date-time"},
+	    "env": {"type": "string", "enum": ["dev", "prod"]},
+	    "home": {"type": "string", "format": "uri"},
+	    "limits": {"type": "object", "properties": {"cpu": {"type": "integer"}}, "required": ["cpu"]},
+	    "name": {"type": "string"},
+	    "owner": {"type": ["null", "string"], "format": "email"},
+	    "port": {"type": "integer"},
+	    "ratio": {"type": "number"},
+	    "tags": {"type": "array", "items": {"type": ["integer", "string"]}}
+	  },
+	  "required": ["created", "env", "home", "name", "port", "ratio", "tags"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inferred := InferSchema(samples...)
+	if !Equal(inferred, expect) {
+		t.Fatalf("unexpected schema:\n%s", RenderDiff(Diff(expect, inferred)))
+	}
+	schema, err := CompileSchema(inferred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sample := range samples {
+		if err := schema.Validate(sample); err != nil {
+			t.Errorf("sample %d does not match the inferred schema: %v", i, err)
+		}
+	}
+
+	inferred = InferSchemaWithOptions(InferOptions{MaxEnum: -1, NoFormats: true}, samples...)
+	for _, pointer := range []string{"/properties/env/enum", "/properties/created/format"} {
+		if _, err := Get(inferred, pointer); err == nil {
+			t.Errorf("expect %s to be disabled", pointer)
+		}
+	}
+	if s, _ := Get(InferSchema(JString("a"), JString("a"), JNumber(1)), "/enum"); s != nil {
+		t.Errorf("enum must not exclude other types, got:%v", s)
+	}
+}
+
+func TestInferSchemaNil(t *testing.T) {
+	schema := InferSchema(nil, JNumber(1))
+	expect, _ := ToValue([]byte(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": ["null", "integer"]}`))
+	if !Equal(expect, schema) {
+		t.Errorf("expect:%v got:%v", expect, schema)
+	}
+}
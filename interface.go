@@ -0,0 +1,156 @@
+package hjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+//InterfaceOptions ToInterfaceWithOptions的选项
+type InterfaceOptions struct {
+	//UseNumber 为true时数字转换为json.Number而不是float64, 不会丢失int64的精度,
+	//与json.Decoder.UseNumber相同
+	UseNumber bool
+}
+
+//ToInterface 把v转换为与encoding/json解码到interface{}相同的结构:
+//map[string]interface{}, []interface{}, string, float64, bool和nil
+func ToInterface(v Value) interface{} {
+	return ToInterfaceWithOptions(v, InterfaceOptions{})
+}
+
+//ToInterfaceWithOptions 按opts把v转换为interface{}
+func ToInterfaceWithOptions(v Value, opts InterfaceOptions) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch x := v.(type) {
+	case JString:
+		return string(x)
+	case JBool:
+		return bool(x)
+	case JNumber:
+		if opts.UseNumber {
+			return json.Number(x.String())
+		}
+		return float64(x)
+	case JFloat:
+		if opts.UseNumber {
+			return json.Number(x.String())
+		}
+		return float64(x)
+	}
+	if obj, ok := AsObject(v); ok {
+		m := make(map[string]interface{}, len(obj.values))
+		for key, value := range obj.values {
+			m[key] = ToInterfaceWithOptions(value, opts)
+		}
+		return m
+	}
+	if array, ok := AsArray(v); ok {
+		s := make([]interface{}, len(array.elements))
+		for i, elem := range array.elements {
+			s[i] = ToInterfaceWithOptions(elem, opts)
+		}
+		return s
+	}
+	return nil
+}
+
+//maxExactFloat float64能精确表示的最大整数
+const maxExactFloat = 1 << 53
+
+//FromInterface 把encoding/json风格的interface{}转换为Value. 除了ToInterface产生的类型,
+//还接受json.Number, 各种整数和浮点数, 以及键为字符串的map和任意元素类型的slice.
+//没有小数部分并且能被float64精确表示的浮点数转换为JNumber
+func FromInterface(x interface{}) (Value, error) {
+	switch x := x.(type) {
+	case nil:
+		return JNull{}, nil
+	case Value:
+		return Clone(x), nil
+	case string:
+		return JString(x), nil
+	case bool:
+		return JBool(x), nil
+	case float64:
+		return fromFloat(x), nil
+	case float32:
+		return fromFloat(float64(x)), nil
+	case json.Number:
+		if n, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+			return JNumber(n), nil
+		}
+		f, err := strconv.ParseFloat(string(x), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number:%s", x)
+		}
+		return JFloat(f), nil
+	case map[string]interface{}:
+		obj := NewObject()
+		for key, value := range x {
+			v, err := FromInterface(value)
+			if err != nil {
+				return nil, err
+			}
+			obj.values[key] = v
+		}
+		return obj, nil
+	case []interface{}:
+		array := &JArray{elements: make([]Value, len(x))}
+		for i, elem := range x {
+			v, err := FromInterface(elem)
+			if err != nil {
+				return nil, err
+			}
+			array.elements[i] = v
+		}
+		return array, nil
+	}
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return JFloat(rv.Uint()), nil
+		}
+		return JNumber(rv.Uint()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return JNumber(rv.Int()), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return JNumber(rv.Uint()), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		obj := NewObject()
+		iter := rv.MapRange()
+		for iter.Next() {
+			v, err := FromInterface(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			obj.values[iter.Key().String()] = v
+		}
+		return obj, nil
+	case reflect.Slice, reflect.Array:
+		array := &JArray{elements: make([]Value, rv.Len())}
+		for i := range array.elements {
+			v, err := FromInterface(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			array.elements[i] = v
+		}
+		return array, nil
+	}
+	return nil, fmt.Errorf("invalid type:%T", x)
+}
+
+func fromFloat(f float64) Value {
+	if f == math.Trunc(f) && f >= -maxExactFloat && f <= maxExactFloat {
+		return JNumber(f)
+	}
+	return JFloat(f)
+}
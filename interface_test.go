@@ -0,0 +1,64 @@
+package hjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToInterface(t *testing.T) {
+	input := `{"a": [1, 2.5, "x", true, null], "b": {"c": -3e2}, "d": 9007199254740993}`
+	value, err := ToValue([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expect interface{}
+	if err := json.Unmarshal([]byte(input), &expect); err != nil {
+		t.Fatal(err)
+	}
+	if got := ToInterface(value); !reflect.DeepEqual(expect, got) {
+		t.Fatalf("expect:%#v got:%#v", expect, got)
+	}
+
+	got := ToInterfaceWithOptions(value, InterfaceOptions{UseNumber: true}).(map[string]interface{})
+	if got["d"] != json.Number("9007199254740993") {
+		t.Fatalf("expect exact number, got:%#v", got["d"])
+	}
+
+	back, err := FromInterface(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualWithOptions(value, back, EqualOptions{NumericEqual: true}) {
+		t.Fatalf("expect round trip, got:%v", back)
+	}
+	back, err = FromInterface(expect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := back.(*JObject).GetInt("b", "c"); n != -300 || Index(back.(*JObject).values["a"].(*JArray), 0) != JNumber(1) {
+		t.Fatalf("expect integral floats to become JNumber, got:%v", back)
+	}
+}
+
+func TestFromInterface(t *testing.T) {
+	value, err := FromInterface(map[string][]int{"a": {1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &JObject{values: map[string]Value{"a": &JArray{elements: []Value{JNumber(1), JNumber(2)}}}}
+	if !Equal(expect, value) {
+		t.Fatalf("expect:%v got:%v", expect, value)
+	}
+	if v, err := FromInterface(uint64(1 << 63)); err != nil || v.Type() != TypeNumber {
+		t.Fatalf("expect number, got:%v %v", v, err)
+	}
+	if v, err := FromInterface([]interface{}{JString("x"), nil}); err != nil || !Equal(v, &JArray{elements: []Value{JString("x"), JNull{}}}) {
+		t.Fatalf("unexpected value:%v %v", v, err)
+	}
+	for _, x := range []interface{}{map[int]string{1: "a"}, struct{}{}, []interface{}{make(chan int)}, json.Number("x")} {
+		if _, err := FromInterface(x); err == nil {
+			t.Errorf("%T: expect error", x)
+		}
+	}
+}
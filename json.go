@@ -2,23 +2,44 @@ package hjson
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 	"strconv"
 )
 
+//JsonType 值的种类, 由Value.Type返回. JNumber和JFloat都是TypeNumber
 type JsonType int
 
 const (
-	invalid JsonType = iota
-	typeObj
-	typeArray
-	typeString
-	typeBool
-	typeNumber
-	typeNull
+	TypeInvalid JsonType = iota
+	TypeObject
+	TypeArray
+	TypeString
+	TypeBool
+	TypeNumber
+	TypeNull
 )
 
+var typeTable = map[JsonType]string{
+	TypeInvalid: "invalid",
+	TypeObject:  "object",
+	TypeArray:   "array",
+	TypeString:  "string",
+	TypeBool:    "bool",
+	TypeNumber:  "number",
+	TypeNull:    "null",
+}
+
+func (t JsonType) String() string {
+	if name, ok := typeTable[t]; ok {
+		return name
+	}
+	return "JsonType(" + strconv.Itoa(int(t)) + ")"
+}
+
 type Value interface {
 	Type() JsonType
 	accept(Walker)
@@ -35,6 +56,9 @@ type JArray struct {
 
 type JNumber int64
 
+//JFloat 带小数或指数的数字, 以及超出int64范围的整数
+type JFloat float64
+
 type JString string
 
 type JBool bool
@@ -43,23 +67,78 @@ type JNull struct {
 }
 
 func (_ JObject) Type() JsonType {
-	return typeObj
+	return TypeObject
 }
 func (_ JArray) Type() JsonType {
-	return typeArray
+	return TypeArray
 }
 func (_ JString) Type() JsonType {
-	return typeString
+	return TypeString
 }
 func (_ JBool) Type() JsonType {
-	return typeBool
+	return TypeBool
 }
 func (_ JNumber) Type() JsonType {
-	return typeNumber
+	return TypeNumber
+}
+func (_ JFloat) Type() JsonType {
+	return TypeNumber
 }
 
 func (_ JNull) Type() JsonType {
-	return typeNull
+	return TypeNull
+}
+
+//IsNull 判断v是否为null, nil也当作null
+func IsNull(v Value) bool {
+	return v == nil || v.Type() == TypeNull
+}
+
+//AsObject 把v转换为*JObject, JObject和*JObject都可以.
+//对JObject值返回的副本与v共享键值对, 但v的map为nil时Set不会反映到v上
+func AsObject(v Value) (*JObject, bool) {
+	switch o := v.(type) {
+	case *JObject:
+		return o, o != nil
+	case JObject:
+		return &o, true
+	}
+	return nil, false
+}
+
+//AsArray 把v转换为*JArray, JArray和*JArray都可以.
+//对JArray值返回的是副本, Append等改变长度的修改不会反映到v上
+func AsArray(v Value) (*JArray, bool) {
+	switch a := v.(type) {
+	case *JArray:
+		return a, a != nil
+	case JArray:
+		return &a, true
+	}
+	return nil, false
+}
+
+//AsString 把v转换为string
+func AsString(v Value) (string, bool) {
+	s, ok := v.(JString)
+	return string(s), ok
+}
+
+//AsBool 把v转换为bool
+func AsBool(v Value) (bool, bool) {
+	b, ok := v.(JBool)
+	return bool(b), ok
+}
+
+//AsFloat 把JNumber或JFloat转换为float64
+func AsFloat(v Value) (float64, bool) {
+	switch n := v.(type) {
+	case JNumber:
+		return float64(n), true
+	case JFloat:
+		return float64(n), true
+	}
+	return 0, false
 }
 
 func NewObject() *JObject {
@@ -78,6 +157,158 @@ func (a *JArray) addValue(v Value) {
 	a.elements = append(a.elements, v)
 }
 
+//Set 设置key的值, 已存在时覆盖. v为nil时设置为JNull
+func (o *JObject) Set(key string, v Value) {
+	if o.values == nil {
+		o.values = make(map[string]Value)
+	}
+	if v == nil {
+		v = JNull{}
+	}
+	o.values[key] = v
+}
+
+//Get 获取key的值
+func (o *JObject) Get(key string) (Value, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+//Delete 删除key, 返回key是否存在
+func (o *JObject) Delete(key string) bool {
+	_, ok := o.values[key]
+	delete(o.values, key)
+	return ok
+}
+
+//Has 判断key是否存在
+func (o *JObject) Has(key string) bool {
+	_, ok := o.values[key]
+	return ok
+}
+
+//Keys 返回所有的键, 按字典序排列. 对象不保留键的插入顺序
+func (o *JObject) Keys() []string {
+	keys := make([]string, 0, len(o.values))
+	for key := range o.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Len 键值对的个数
+func (o *JObject) Len() int {
+	return len(o.values)
+}
+
+//Range 按Keys的顺序对每个键值对调用fn, fn返回false时停止.
+//fn中可以修改对象, 新增的键不会被访问
+func (o *JObject) Range(fn func(key string, v Value) bool) {
+	for _, key := range o.Keys() {
+		v, ok := o.values[key]
+		if !ok {
+			continue
+		}
+		if !fn(key, v) {
+			return
+		}
+	}
+}
+
+//Len 元素的个数
+func (a *JArray) Len() int {
+	return len(a.elements)
+}
+
+//At 获取第i个元素, 越界时返回IndexError
+func (a *JArray) At(i int) (Value, error) {
+	if err := a.check(i, false); err != nil {
+		return nil, err
+	}
+	return a.elements[i], nil
+}
+
+//SetAt 替换第i个元素, 越界时返回IndexError
+func (a *JArray) SetAt(i int, v Value) error {
+	if err := a.check(i, false); err != nil {
+		return err
+	}
+	if v == nil {
+		v = JNull{}
+	}
+	a.elements[i] = v
+	return nil
+}
+
+//Append 在末尾添加元素, nil元素添加为JNull
+func (a *JArray) Append(vs ...Value) {
+	for _, v := range vs {
+		if v == nil {
+			v = JNull{}
+		}
+		a.elements = append(a.elements, v)
+	}
+}
+
+//Insert 在第i个元素之前插入v, i等于Len时添加到末尾
+func (a *JArray) Insert(i int, v Value) error {
+	if err := a.check(i, true); err != nil {
+		return err
+	}
+	if v == nil {
+		v = JNull{}
+	}
+	a.elements = append(a.elements, nil)
+	copy(a.elements[i+1:], a.elements[i:])
+	a.elements[i] = v
+	return nil
+}
+
+//RemoveAt 删除并返回第i个元素
+func (a *JArray) RemoveAt(i int) (Value, error) {
+	if err := a.check(i, false); err != nil {
+		return nil, err
+	}
+	v := a.elements[i]
+	copy(a.elements[i:], a.elements[i+1:])
+	a.elements[len(a.elements)-1] = nil
+	a.elements = a.elements[:len(a.elements)-1]
+	return v, nil
+}
+
+//Slice 返回包含[from, to)元素的新数组, 元素本身不会被复制
+func (a *JArray) Slice(from, to int) (*JArray, error) {
+	if err := a.check(from, true); err != nil {
+		return nil, err
+	}
+	if err := a.check(to, true); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid slice index %d > %d", from, to)
+	}
+	return &JArray{elements: append([]Value{}, a.elements[from:to]...)}, nil
+}
+
+//Range 依次对每个元素调用fn, fn返回false时停止
+func (a *JArray) Range(fn func(i int, v Value) bool) {
+	for i, v := range a.elements {
+		if !fn(i, v) {
+			return
+		}
+	}
+}
+
+//check 检查0 <= i < Len, end为true时i也可以等于Len
+func (a *JArray) check(i int, end bool) error {
+	n := len(a.elements)
+	if i < 0 || i > n || i == n && !end {
+		return &IndexError{Index: i, Len: n, End: end}
+	}
+	return nil
+}
+
 func (o JObject) String() string {
 	visitor := newNodeVisitor()
 	o.accept(visitor)
@@ -94,6 +325,9 @@ func (s JString) String() string {
 func (n JNumber) String() string {
 	return strconv.Itoa(int(n))
 }
+func (f JFloat) String() string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
 func (b JBool) String() string {
 	if b {
 		return "true"
@@ -139,6 +373,8 @@ func converBaseType(value interface{}) (Value, bool) {
 		return JNumber(v.Uint()), true
 	case reflect.Uint:
 		return JNumber(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return JFloat(v.Float()), true
 	}
 	return nil, false
 }
@@ -228,27 +464,26 @@ TODO: 提供一些接口,那么应该提供哪些接口。
 
 //AddArrayElement 添加元素到数组
 func AddArrayElement(array *JArray, value interface{}) {
-	jv := toJSONValue(value)
-	array.elements = append(array.elements, jv)
+	array.Append(toJSONValue(value))
 }
 
 //AddValue 添加新对象
 func AddValue(obj *JObject, key string, value interface{}) {
-	obj.values[key] = toJSONValue(value)
+	obj.Set(key, toJSONValue(value))
 }
 
 //GetObjField 从对象获取指定的键值对
 func GetObjField(obj *JObject, key string) (Value, bool) {
-	v, ok := obj.values[key]
-	return v, ok
+	return obj.Get(key)
 }
 
-//Index 获取指定位置的值
+//Index 获取指定位置的值, 越界时panic. 不想panic时使用JArray.At
 func Index(value *JArray, index int) Value {
-	if index < 0 || index > len(value.elements) {
-		panic("")
+	v, err := value.At(index)
+	if err != nil {
+		panic(err)
 	}
-	return value.elements[index]
+	return v
 }
 
 func ToValue(data []byte) (Value, error) {
@@ -259,3 +494,67 @@ func ToValue(data []byte) (Value, error) {
 	}
 	return value, nil
 }
+
+//Decoder 从输入流中依次读取多个文档(多文档模式), 文档之间用空白分隔
+type Decoder struct {
+	p       *parser
+	started bool
+	err     error
+}
+
+//NewDecoder 创建从r读取文档的Decoder
+func NewDecoder(r io.Reader, opts Options) *Decoder {
+	return &Decoder{
+		p: newParserWithOptions(r, opts),
+	}
+}
+
+//DecodeContext 与Decode相同, 但在读取输入时会定期检查ctx,
+//ctx被取消或超时后返回ctx.Err(), 之后的调用都返回同一个错误.
+//检查发生在两次读取之间, 无法打断一次阻塞中的Read
+func (d *Decoder) DecodeContext(ctx context.Context) (Value, error) {
+	d.p.jscanner.ctx = ctx
+	defer func() {
+		d.p.jscanner.ctx = nil
+	}()
+	return d.Decode()
+}
+
+//Decode 读取下一个文档, 没有更多文档时返回io.EOF.
+//为了确定文档已经结束, Decode返回前会预读下一个文档的第一个记号.
+//非恢复模式下出错后, 之后的调用都返回同一个错误
+func (d *Decoder) Decode() (Value, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	p := d.p
+	if !d.started {
+		p.start()
+		d.started = true
+	}
+	if p.token == tokenEOF && p.jscanner.err == nil {
+		return nil, io.EOF
+	}
+	p.errs = nil
+	value, err := p.parseDocument()
+	if err != nil {
+		d.err = err
+		return nil, err
+	}
+	return value, p.errs.Err()
+}
+
+//ToValueWithOptions 按opts解析data. 恢复模式下即使有错误也返回部分结果,
+//错误类型为ErrorList
+func ToValueWithOptions(data []byte, opts Options) (Value, error) {
+	parser := newParserWithOptions(bytes.NewBuffer(data), opts)
+	return parser.parse()
+}
+
+//ToValueContext 与ToValueWithOptions相同, 但在解析过程中会定期检查ctx,
+//ctx被取消或超时后返回ctx.Err()
+func ToValueContext(ctx context.Context, data []byte, opts Options) (Value, error) {
+	parser := newParserWithOptions(bytes.NewBuffer(data), opts)
+	parser.jscanner.ctx = ctx
+	return parser.parse()
+}
@@ -1,6 +1,14 @@
 package hjson
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestAddValue(t *testing.T) {
 	testCases := []struct {
@@ -90,3 +98,199 @@ func TestAddValue(t *testing.T) {
 		}
 	}
 }
+
+func TestDecoder(t *testing.T) {
+	input := `{"a":1}
+	[1, 2] {"b":[true]}
+	`
+	expect := []Value{
+		&JObject{values: map[string]Value{"a": JNumber(1)}},
+		&JArray{elements: []Value{JNumber(1), JNumber(2)}},
+		&JObject{values: map[string]Value{"b": &JArray{elements: []Value{JBool(true)}}}},
+	}
+	d := NewDecoder(bytes.NewBufferString(input), Options{})
+	for i, e := range expect {
+		v, err := d.Decode()
+		if err != nil {
+			t.Fatalf("case:%d %v", i, err)
+		}
+		if err := check(e, v); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Fatalf("expect io.EOF, got:%v", err)
+	}
+
+	d = NewDecoder(bytes.NewBufferString(`[1] [2`), Options{})
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err == nil || err == io.EOF {
+		t.Fatalf("expect syntax error, got:%v", err)
+	}
+}
+
+//endlessArray 产生无穷无尽的"[1,1,1,...", 读取一定字节后调用cancel
+type endlessArray struct {
+	n      int
+	cancel func()
+}
+
+func (e *endlessArray) Read(p []byte) (int, error) {
+	for i := range p {
+		if e.n == 0 {
+			p[i] = '['
+		} else if e.n%2 == 1 {
+			p[i] = '1'
+		} else {
+			p[i] = ','
+		}
+		e.n++
+	}
+	if e.n > 1<<16 {
+		e.cancel()
+	}
+	return len(p), nil
+}
+
+func TestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ToValueContext(ctx, []byte(`[1, 2]`), Options{}); err != context.Canceled {
+		t.Fatalf("expect context.Canceled, got:%v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	d := NewDecoder(&endlessArray{cancel: cancel}, Options{Recover: true})
+	if _, err := d.DecodeContext(ctx); err != context.Canceled {
+		t.Fatalf("expect context.Canceled, got:%v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	value, err := ToValueContext(ctx, []byte(`{"a":[1, 2]}`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &JObject{values: map[string]Value{"a": &JArray{elements: []Value{JNumber(1), JNumber(2)}}}}
+	if err := check(expect, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutate(t *testing.T) {
+	obj := &JObject{}
+	obj.Set("b", JNumber(2))
+	obj.Set("a", nil)
+	obj.Set("c", JString("c"))
+	if !obj.Delete("c") || obj.Delete("c") {
+		t.Fatal("delete should report whether the key existed")
+	}
+	if !obj.Has("a") || obj.Has("c") || obj.Len() != 2 {
+		t.Fatalf("unexpected object:%v", obj)
+	}
+	var keys []string
+	obj.Range(func(key string, v Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if strings.Join(keys, ",") != "a,b" || strings.Join(obj.Keys(), ",") != "a,b" {
+		t.Fatalf("expect sorted keys, got:%v", keys)
+	}
+
+	array := NewArray()
+	array.Append(JNumber(1), JNumber(3))
+	if err := array.Insert(1, JNumber(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := array.Insert(3, JNumber(4)); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := array.RemoveAt(0); err != nil || v != JNumber(1) {
+		t.Fatalf("expect 1, got:%v %v", v, err)
+	}
+	slice, err := array.Slice(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slice.Append(JNumber(5))
+	if err := check(&JArray{elements: []Value{JNumber(2), JNumber(3), JNumber(4)}}, array); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(&JArray{elements: []Value{JNumber(3), JNumber(4), JNumber(5)}}, slice); err != nil {
+		t.Fatal(err)
+	}
+	sum := 0
+	array.Range(func(i int, v Value) bool {
+		sum += int(v.(JNumber))
+		return i < 1
+	})
+	if sum != 5 {
+		t.Fatalf("expect range to stop after 2 elements, got sum %d", sum)
+	}
+
+	var indexErr *IndexError
+	if _, err := array.At(3); !errors.As(err, &indexErr) || indexErr.Index != 3 || indexErr.Len != 3 {
+		t.Fatalf("expect IndexError, got:%v", err)
+	}
+	if err := array.Insert(5, JNull{}); err == nil || err.Error() != "index 5 out of range [0, 3]" {
+		t.Fatalf("expect error inserting out of range, got:%v", err)
+	}
+	if _, err := array.Slice(0, 4); err == nil || err.Error() != "index 4 out of range [0, 3]" {
+		t.Fatalf("expect error slicing out of range, got:%v", err)
+	}
+	if _, err := array.RemoveAt(-1); err == nil {
+		t.Fatal("expect error removing out of range")
+	}
+	if _, err := array.Slice(2, 1); err == nil {
+		t.Fatal("expect error for reversed slice")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expect Index to panic at len")
+		}
+	}()
+	Index(array, 3)
+}
+
+func TestTypeInspection(t *testing.T) {
+	value, err := ToValue([]byte(`[{"a": 1}, [], "s", true, 1.5, null]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	array, ok := AsArray(value)
+	if !ok {
+		t.Fatalf("expect array, got:%v", value.Type())
+	}
+	var types []string
+	array.Range(func(i int, v Value) bool {
+		types = append(types, v.Type().String())
+		return true
+	})
+	if strings.Join(types, ",") != "object,array,string,bool,number,null" {
+		t.Fatalf("unexpected types:%v", types)
+	}
+	if JsonType(42).String() != "JsonType(42)" {
+		t.Fatalf("unexpected name:%s", JsonType(42))
+	}
+	if obj, ok := AsObject(JObject{values: map[string]Value{"a": JNull{}}}); !ok || !obj.Has("a") {
+		t.Fatal("expect AsObject to accept a JObject value")
+	}
+	if _, ok := AsObject(Index(array, 1)); ok {
+		t.Fatal("expect AsObject to reject an array")
+	}
+	if s, ok := AsString(Index(array, 2)); !ok || s != "s" {
+		t.Fatalf("expect s, got:%q", s)
+	}
+	if b, ok := AsBool(Index(array, 3)); !ok || !b {
+		t.Fatal("expect true")
+	}
+	if f, ok := AsFloat(Index(array, 4)); !ok || f != 1.5 {
+		t.Fatalf("expect 1.5, got:%v", f)
+	}
+	if !IsNull(Index(array, 5)) || !IsNull(nil) || IsNull(Index(array, 0)) {
+		t.Fatal("unexpected IsNull result")
+	}
+}
@@ -0,0 +1,969 @@
+package hjson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//Node JSONPath查询的结果: 匹配的值和它的规范化路径, Path.String()即RFC 9535的规范化路径
+type Node struct {
+	Path  Path
+	Value Value
+}
+
+//JSONPathError JSONPath查询的语法错误, Offset为出错的字节偏移
+type JSONPathError struct {
+	Query  string
+	Offset int
+	Msg    string
+}
+
+func (e *JSONPathError) Error() string {
+	return fmt.Sprintf("jsonpath %q: offset %d: %s", e.Query, e.Offset, e.Msg)
+}
+
+//JSONPath 编译好的RFC 9535 JSONPath查询, 可以被多个goroutine同时使用
+type JSONPath struct {
+	query    string
+	segments []pathSegment
+}
+
+//CompileJSONPath 编译JSONPath查询, 如$.servers[*].host或$..[?@.enabled == true]
+func CompileJSONPath(query string) (*JSONPath, error) {
+	p := &pathParser{src: query}
+	if !p.consume("$") {
+		return nil, p.errorf("query must start with '$'")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.src) {
+		return nil, p.errorf("unexpected character %q", p.src[p.pos])
+	}
+	return &JSONPath{query: query, segments: segments}, nil
+}
+
+//MustCompileJSONPath 同CompileJSONPath, 出错时panic
+func MustCompileJSONPath(query string) *JSONPath {
+	p, err := CompileJSONPath(query)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *JSONPath) String() string {
+	return p.query
+}
+
+//Query 返回v中匹配的所有节点. 对象的成员按键的字典序访问
+func (p *JSONPath) Query(v Value) []Node {
+	return evalSegments(p.segments, []Node{{Path: Path{}, Value: v}}, v)
+}
+
+//Query 编译并执行JSONPath查询
+func Query(v Value, query string) ([]Node, error) {
+	p, err := CompileJSONPath(query)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(v), nil
+}
+
+//===============================求值========================
+
+type pathSegment struct {
+	descendant bool
+	selectors  []pathSelector
+}
+
+type pathSelector interface {
+	selectFrom(n Node, root Value, out []Node) []Node
+}
+
+func evalSegments(segments []pathSegment, nodes []Node, root Value) []Node {
+	for _, seg := range segments {
+		var out []Node
+		for _, n := range nodes {
+			if seg.descendant {
+				descend(n, func(d Node) {
+					out = seg.apply(d, root, out)
+				})
+			} else {
+				out = seg.apply(n, root, out)
+			}
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+func (s pathSegment) apply(n Node, root Value, out []Node) []Node {
+	for _, sel := range s.selectors {
+		out = sel.selectFrom(n, root, out)
+	}
+	return out
+}
+
+//descend 先序遍历n和它的所有后代
+func descend(n Node, fn func(Node)) {
+	fn(n)
+	children(n, func(c Node) {
+		descend(c, fn)
+	})
+}
+
+//children 按顺序访问n的子节点
+func children(n Node, fn func(Node)) {
+	if obj, ok := AsObject(n.Value); ok {
+		for _, key := range obj.Keys() {
+			fn(child(n, key, obj.values[key]))
+		}
+	} else if array, ok := AsArray(n.Value); ok {
+		for i, elem := range array.elements {
+			fn(child(n, i, elem))
+		}
+	}
+}
+
+func child(n Node, seg interface{}, v Value) Node {
+	path := make(Path, len(n.Path)+1)
+	copy(path, n.Path)
+	path[len(n.Path)] = seg
+	return Node{Path: path, Value: v}
+}
+
+type nameSelector string
+
+func (s nameSelector) selectFrom(n Node, root Value, out []Node) []Node {
+	if obj, ok := AsObject(n.Value); ok {
+		if v, ok := obj.values[string(s)]; ok {
+			out = append(out, child(n, string(s), v))
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(n Node, root Value, out []Node) []Node {
+	children(n, func(c Node) {
+		out = append(out, c)
+	})
+	return out
+}
+
+type indexSelector int
+
+func (s indexSelector) selectFrom(n Node, root Value, out []Node) []Node {
+	if array, ok := AsArray(n.Value); ok {
+		i := int(s)
+		if i < 0 {
+			i += len(array.elements)
+		}
+		if i >= 0 && i < len(array.elements) {
+			out = append(out, child(n, i, array.elements[i]))
+		}
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(n Node, root Value, out []Node) []Node {
+	array, ok := AsArray(n.Value)
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := len(array.elements)
+	normalize := func(i *int, def int) int {
+		if i == nil {
+			return def
+		}
+		if *i < 0 {
+			return length + *i
+		}
+		return *i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+	if s.step > 0 {
+		lower := clamp(normalize(s.start, 0), 0, length)
+		upper := clamp(normalize(s.end, length), 0, length)
+		for i := lower; i < upper; i += s.step {
+			out = append(out, child(n, i, array.elements[i]))
+		}
+		return out
+	}
+	upper := clamp(normalize(s.start, length-1), -1, length-1)
+	lower := clamp(normalize(s.end, -length-1), -1, length-1)
+	for i := upper; lower < i; i += s.step {
+		out = append(out, child(n, i, array.elements[i]))
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+func (s filterSelector) selectFrom(n Node, root Value, out []Node) []Node {
+	children(n, func(c Node) {
+		if s.expr.test(c.Value, root) {
+			out = append(out, c)
+		}
+	})
+	return out
+}
+
+//logicalExpr 过滤表达式中结果为LogicalType的表达式
+type logicalExpr interface {
+	test(current, root Value) bool
+}
+
+//operand 比较的操作数, ok为false表示Nothing
+type operand interface {
+	eval(current, root Value) (v Value, ok bool)
+}
+
+type orExpr []logicalExpr
+
+func (e orExpr) test(current, root Value) bool {
+	for _, x := range e {
+		if x.test(current, root) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (e andExpr) test(current, root Value) bool {
+	for _, x := range e {
+		if !x.test(current, root) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr logicalExpr
+}
+
+func (e notExpr) test(current, root Value) bool {
+	return !e.expr.test(current, root)
+}
+
+//filterQuery @或$开始的查询, 作为测试表达式时表示结果非空
+type filterQuery struct {
+	relative bool
+	segments []pathSegment
+}
+
+func (q *filterQuery) nodes(current, root Value) []Node {
+	start := root
+	if q.relative {
+		start = current
+	}
+	return evalSegments(q.segments, []Node{{Value: start}}, root)
+}
+
+func (q *filterQuery) test(current, root Value) bool {
+	return len(q.nodes(current, root)) > 0
+}
+
+//singular 只包含名字和下标选择器的查询最多产生一个节点, 可以作为比较的操作数
+func (q *filterQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (q *filterQuery) eval(current, root Value) (Value, bool) {
+	nodes := q.nodes(current, root)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].Value, true
+}
+
+type literal struct {
+	v Value
+}
+
+func (l literal) eval(current, root Value) (Value, bool) {
+	return l.v, true
+}
+
+type compareExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e compareExpr) test(current, root Value) bool {
+	a, aok := e.left.eval(current, root)
+	b, bok := e.right.eval(current, root)
+	switch e.op {
+	case "==":
+		return pathEqual(a, aok, b, bok)
+	case "!=":
+		return !pathEqual(a, aok, b, bok)
+	case "<":
+		return pathLess(a, aok, b, bok)
+	case "<=":
+		return pathLess(a, aok, b, bok) || pathEqual(a, aok, b, bok)
+	case ">":
+		return pathLess(b, bok, a, aok)
+	case ">=":
+		return pathLess(b, bok, a, aok) || pathEqual(a, aok, b, bok)
+	}
+	return false
+}
+
+func pathEqual(a Value, aok bool, b Value, bok bool) bool {
+	if !aok || !bok {
+		return aok == bok
+	}
+	return EqualWithOptions(a, b, EqualOptions{NumericEqual: true})
+}
+
+//pathLess 只有数字和字符串之间可以比较大小
+func pathLess(a Value, aok bool, b Value, bok bool) bool {
+	if !aok || !bok || a.Type() != b.Type() {
+		return false
+	}
+	switch a.Type() {
+	case TypeNumber:
+		return compareNumbers(a, b) < 0
+	case TypeString:
+		return Compare(a, b) < 0
+	}
+	return false
+}
+
+//函数参数和返回值的类型
+const (
+	valueType = iota
+	logicalType
+	nodesType
+)
+
+type pathFunc struct {
+	params []int
+	result int
+}
+
+var pathFuncs = map[string]pathFunc{
+	"length": {[]int{valueType}, valueType},
+	"count":  {[]int{nodesType}, valueType},
+	"match":  {[]int{valueType, valueType}, logicalType},
+	"search": {[]int{valueType, valueType}, logicalType},
+	"value":  {[]int{nodesType}, valueType},
+}
+
+type funcCall struct {
+	name string
+	args []interface{}
+}
+
+func (f *funcCall) eval(current, root Value) (Value, bool) {
+	switch f.name {
+	case "length":
+		v, ok := f.args[0].(operand).eval(current, root)
+		if !ok {
+			return nil, false
+		}
+		if s, ok := AsString(v); ok {
+			return JNumber(utf8.RuneCountInString(s)), true
+		}
+		if obj, ok := AsObject(v); ok {
+			return JNumber(obj.Len()), true
+		}
+		if array, ok := AsArray(v); ok {
+			return JNumber(array.Len()), true
+		}
+	case "count":
+		return JNumber(len(f.args[0].(*filterQuery).nodes(current, root))), true
+	case "value":
+		nodes := f.args[0].(*filterQuery).nodes(current, root)
+		if len(nodes) == 1 {
+			return nodes[0].Value, true
+		}
+	}
+	return nil, false
+}
+
+func (f *funcCall) test(current, root Value) bool {
+	v, ok := f.args[0].(operand).eval(current, root)
+	s, isStr := AsString(v)
+	p, pok := f.args[1].(operand).eval(current, root)
+	pattern, isPattern := AsString(p)
+	if !ok || !pok || !isStr || !isPattern {
+		return false
+	}
+	if f.name == "match" {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	re, err := regexp.Compile(iregexp(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+//iregexp 把RFC 9485的I-Regexp转换为Go的正则表达式, 两者的区别在于'.'不匹配\n和\r
+func iregexp(pattern string) string {
+	var b strings.Builder
+	class := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[':
+			class = true
+		case c == ']':
+			class = false
+		case c == '.' && !class:
+			b.WriteString(`[^\n\r]`)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+//===============================解析========================
+
+type pathParser struct {
+	src string
+	pos int
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) *JSONPathError {
+	return &JSONPathError{Query: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) skipBlank() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+//parseSegments 解析若干段, 段之间可以有空白. 之后不是段时回到空白之前
+func (p *pathParser) parseSegments() ([]pathSegment, error) {
+	var segments []pathSegment
+	for {
+		start := p.pos
+		p.skipBlank()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.pos = start
+			return segments, nil
+		}
+		seg, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+}
+
+func (p *pathParser) parseSegment() (pathSegment, error) {
+	var seg pathSegment
+	if p.consume("..") {
+		seg.descendant = true
+		if p.peek() == '[' {
+			return p.parseBracketed(seg)
+		}
+	} else if !p.consume(".") {
+		return p.parseBracketed(seg)
+	}
+	if p.consume("*") {
+		seg.selectors = []pathSelector{wildcardSelector{}}
+		return seg, nil
+	}
+	name := p.parseMemberName()
+	if name == "" {
+		return seg, p.errorf("expect member name or '*'")
+	}
+	seg.selectors = []pathSelector{nameSelector(name)}
+	return seg, nil
+}
+
+func isNameFirst(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r >= 0x80
+}
+
+func (p *pathParser) parseMemberName() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !isNameFirst(r) && (p.pos == start || !isDigit(r)) {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *pathParser) parseBracketed(seg pathSegment) (pathSegment, error) {
+	if !p.consume("[") {
+		return seg, p.errorf("expect '['")
+	}
+	for {
+		p.skipBlank()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = append(seg.selectors, sel)
+		p.skipBlank()
+		if p.consume("]") {
+			return seg, nil
+		}
+		if !p.consume(",") {
+			return seg, p.errorf("expect ',' or ']'")
+		}
+	}
+}
+
+func (p *pathParser) parseSelector() (pathSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return nameSelector(s), err
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+		expr, err := p.parseOr()
+		return filterSelector{expr}, err
+	}
+	start, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	save := p.pos
+	p.skipBlank()
+	if !p.consume(":") {
+		if start == nil {
+			return nil, p.errorf("invalid selector")
+		}
+		p.pos = save
+		return indexSelector(*start), nil
+	}
+	sel := sliceSelector{start: start, step: 1}
+	p.skipBlank()
+	if sel.end, err = p.parseOptionalInt(); err != nil {
+		return nil, err
+	}
+	save = p.pos
+	p.skipBlank()
+	if !p.consume(":") {
+		p.pos = save
+		return sel, nil
+	}
+	p.skipBlank()
+	step, err := p.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	if step != nil {
+		sel.step = *step
+	}
+	return sel, nil
+}
+
+//maxPathInt I-JSON中能精确表示的最大整数
+const maxPathInt = 1<<53 - 1
+
+//parseOptionalInt 解析整数, 不允许前导0和-0. 没有整数时返回nil
+func (p *pathParser) parseOptionalInt() (*int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for p.pos < len(p.src) && isDigit(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	lit := p.src[start:p.pos]
+	switch {
+	case p.pos == digits && p.pos == start:
+		return nil, nil
+	case p.pos == digits, p.src[digits] == '0' && (p.pos-digits > 1 || digits > start):
+		p.pos = start
+		return nil, p.errorf("invalid integer %q", lit)
+	}
+	n, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil || n > maxPathInt || n < -maxPathInt {
+		p.pos = start
+		return nil, p.errorf("integer %s out of range", lit)
+	}
+	i := int(n)
+	return &i, nil
+}
+
+//parseString 解析单引号或双引号的字符串字面值
+func (p *pathParser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorf("unterminated string")
+		}
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		switch {
+		case r == rune(quote):
+			p.pos++
+			return b.String(), nil
+		case r < 0x20:
+			return "", p.errorf("invalid control character %q in string", r)
+		case r != '\\':
+			b.WriteRune(r)
+			p.pos += size
+			continue
+		}
+		p.pos++
+		c := p.peek()
+		p.pos++
+		switch c {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\':
+			b.WriteByte(c)
+		case 'u':
+			r, err := p.parseHex4()
+			if err != nil {
+				return "", err
+			}
+			if utf16.IsSurrogate(r) {
+				if !p.consume(`\u`) {
+					return "", p.errorf("unpaired surrogate")
+				}
+				low, err := p.parseHex4()
+				if err != nil {
+					return "", err
+				}
+				if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+					return "", p.errorf("invalid surrogate pair")
+				}
+			}
+			b.WriteRune(r)
+		default:
+			if c != quote {
+				p.pos--
+				return "", p.errorf("invalid escape sequence")
+			}
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *pathParser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.src) {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	for i := 0; i < 4; i++ {
+		if !isHexDigit(rune(p.src[p.pos+i])) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+	}
+	n, _ := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+	p.pos += 4
+	return rune(n), nil
+}
+
+func (p *pathParser) parseOr() (logicalExpr, error) {
+	var or orExpr
+	for {
+		and, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, and)
+		save := p.pos
+		p.skipBlank()
+		if !p.consume("||") {
+			p.pos = save
+			break
+		}
+		p.skipBlank()
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *pathParser) parseAnd() (logicalExpr, error) {
+	var and andExpr
+	for {
+		basic, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, basic)
+		save := p.pos
+		p.skipBlank()
+		if !p.consume("&&") {
+			p.pos = save
+			break
+		}
+		p.skipBlank()
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *pathParser) parseBasic() (logicalExpr, error) {
+	if p.consume("!") {
+		p.skipBlank()
+		if p.peek() == '(' {
+			expr, err := p.parseParen()
+			return notExpr{expr}, err
+		}
+		expr, err := p.parseTest()
+		return notExpr{expr}, err
+	}
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+	start := p.pos
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	save := p.pos
+	p.skipBlank()
+	op := ""
+	for _, o := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		p.pos = save
+		if test, ok := left.(logicalExpr); ok && p.testable(left) {
+			return test, nil
+		}
+		p.pos = start
+		return nil, p.errorf("expect a test or comparison expression")
+	}
+	l, err := p.comparable(left, start)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	start = p.pos
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	r, err := p.comparable(right, start)
+	if err != nil {
+		return nil, err
+	}
+	return compareExpr{op: op, left: l, right: r}, nil
+}
+
+func (p *pathParser) parseParen() (logicalExpr, error) {
+	p.consume("(")
+	p.skipBlank()
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.consume(")") {
+		return nil, p.errorf("expect ')'")
+	}
+	return expr, nil
+}
+
+//parseTest 解析测试表达式: 查询或者返回LogicalType的函数
+func (p *pathParser) parseTest() (logicalExpr, error) {
+	start := p.pos
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if test, ok := x.(logicalExpr); ok && p.testable(x) {
+		return test, nil
+	}
+	p.pos = start
+	return nil, p.errorf("expect a filter query or a logical function")
+}
+
+func (p *pathParser) testable(x interface{}) bool {
+	if f, ok := x.(*funcCall); ok {
+		return pathFuncs[f.name].result == logicalType
+	}
+	_, ok := x.(*filterQuery)
+	return ok
+}
+
+//comparable 检查x是否可以作为比较的操作数: 字面值, 单一查询或返回ValueType的函数
+func (p *pathParser) comparable(x interface{}, start int) (operand, error) {
+	switch x := x.(type) {
+	case literal:
+		return x, nil
+	case *filterQuery:
+		if x.singular() {
+			return x, nil
+		}
+	case *funcCall:
+		if pathFuncs[x.name].result == valueType {
+			return x, nil
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("not comparable: expect a literal, singular query or value function")
+}
+
+//parsePrimary 解析字面值, 查询或函数调用
+func (p *pathParser) parsePrimary() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+		return &filterQuery{relative: c == '@', segments: segments}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return literal{JString(s)}, err
+	case c == '-' || isDigit(rune(c)):
+		n := numberPrefix(p.src[p.pos:])
+		if n == 0 {
+			return nil, p.errorf("invalid number")
+		}
+		lit := p.src[p.pos : p.pos+n]
+		p.pos += n
+		if i, err := strconv.ParseInt(lit, 10, 64); err == nil {
+			return literal{JNumber(i)}, nil
+		}
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", lit)
+		}
+		return literal{JFloat(f)}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z' ||
+		p.pos > start && (p.src[p.pos] == '_' || isDigit(rune(p.src[p.pos])))) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if p.peek() == '(' {
+		return p.parseCall(name, start)
+	}
+	switch name {
+	case "true":
+		return literal{JBool(true)}, nil
+	case "false":
+		return literal{JBool(false)}, nil
+	case "null":
+		return literal{JNull{}}, nil
+	}
+	p.pos = start
+	return nil, p.errorf("unexpected token")
+}
+
+func (p *pathParser) parseCall(name string, start int) (*funcCall, error) {
+	fn, ok := pathFuncs[name]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function %q", name)
+	}
+	p.consume("(")
+	call := &funcCall{name: name}
+	for i, param := range fn.params {
+		p.skipBlank()
+		if i > 0 {
+			if !p.consume(",") {
+				return nil, p.errorf("function %s expects %d arguments", name, len(fn.params))
+			}
+			p.skipBlank()
+		}
+		argStart := p.pos
+		x, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if param == nodesType {
+			q, ok := x.(*filterQuery)
+			if !ok {
+				p.pos = argStart
+				return nil, p.errorf("function %s expects a filter query", name)
+			}
+			call.args = append(call.args, q)
+			continue
+		}
+		arg, err := p.comparable(x, argStart)
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	p.skipBlank()
+	if !p.consume(")") {
+		return nil, p.errorf("function %s expects %d arguments", name, len(fn.params))
+	}
+	return call, nil
+}
@@ -0,0 +1,139 @@
+package hjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+//RFC 9535第1.5节的例子
+const bookstore = `{ "store": {
+    "book": [
+      { "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      { "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      { "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      },
+      { "category": "fiction",
+        "author": "J. R. R. Tolkien",
+        "title": "The Lord of the Rings",
+        "isbn": "0-395-19395-8",
+        "price": 22.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 399
+    }
+  }
+}`
+
+func TestJSONPath(t *testing.T) {
+	store, err := ToValue([]byte(bookstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		query  string
+		expect []string
+	}{
+		{`$.store.book[*].author`, []string{
+			"$['store']['book'][0]['author']", "$['store']['book'][1]['author']",
+			"$['store']['book'][2]['author']", "$['store']['book'][3]['author']"}},
+		{`$..author`, []string{
+			"$['store']['book'][0]['author']", "$['store']['book'][1]['author']",
+			"$['store']['book'][2]['author']", "$['store']['book'][3]['author']"}},
+		{`$.store.*`, []string{"$['store']['bicycle']", "$['store']['book']"}},
+		{`$.store..price`, []string{"$['store']['bicycle']['price']",
+			"$['store']['book'][0]['price']", "$['store']['book'][1]['price']",
+			"$['store']['book'][2]['price']", "$['store']['book'][3]['price']"}},
+		{`$..book[2]`, []string{"$['store']['book'][2]"}},
+		{`$..book[-1]`, []string{"$['store']['book'][3]"}},
+		{`$..book[0,1]`, []string{"$['store']['book'][0]", "$['store']['book'][1]"}},
+		{`$..book[:2]`, []string{"$['store']['book'][0]", "$['store']['book'][1]"}},
+		{`$..book[::-2]`, []string{"$['store']['book'][3]", "$['store']['book'][1]"}},
+		{`$..book[1:3:0]`, nil},
+		{`$..book[?@.isbn]`, []string{"$['store']['book'][2]", "$['store']['book'][3]"}},
+		{`$..book[?@.price<10]`, []string{"$['store']['book'][0]", "$['store']['book'][2]"}},
+		{`$..book[?(@.price < 10 && !(@.category == 'reference'))].title`, []string{"$['store']['book'][2]['title']"}},
+		{`$..book[?@.price > $.store.bicycle.price || @.author == "Evelyn Waugh"]`, []string{"$['store']['book'][1]"}},
+		{`$..book[?length(@.title) > 15]`, []string{"$['store']['book'][0]", "$['store']['book'][3]"}},
+		{`$..book[?match(@.author, 'J.*')]`, []string{"$['store']['book'][3]"}},
+		{`$..book[?search(@.title, "of")]`, []string{"$['store']['book'][0]", "$['store']['book'][1]", "$['store']['book'][3]"}},
+		{`$.store[?count(@.*) == 2]`, []string{"$['store']['bicycle']"}},
+		{`$.store[?value(@.color) == "red"]`, []string{"$['store']['bicycle']"}},
+		{`$.store.bicycle[?@.missing == $.nothing]`, []string{
+			"$['store']['bicycle']['color']", "$['store']['bicycle']['price']"}},
+		{`$ ["store"] [ 'book' , 'x' ] [ 0 ].price`, []string{"$['store']['book'][0]['price']"}},
+		{`$`, []string{"$"}},
+	}
+	for _, tc := range cases {
+		nodes, err := Query(store, tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		var paths []string
+		for _, n := range nodes {
+			paths = append(paths, n.Path.String())
+			if got, err := Get(store, n.Path.Pointer()); err != nil || !Equal(got, n.Value) {
+				t.Errorf("%s: node %s does not match its path", tc.query, n.Path)
+			}
+		}
+		if strings.Join(paths, "\n") != strings.Join(tc.expect, "\n") {
+			t.Errorf("%s: expect:\n%s\ngot:\n%s", tc.query, strings.Join(tc.expect, "\n"), strings.Join(paths, "\n"))
+		}
+	}
+
+	servers, err := ToValue([]byte(`{"servers": [{"host": "a", "enabled": true}, {"host": "b", "enabled": false}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := MustCompileJSONPath(`$.servers[*].host`).Query(servers)
+	if len(hosts) != 2 || hosts[0].Value != JString("a") || hosts[1].Value != JString("b") {
+		t.Fatalf("unexpected hosts:%v", hosts)
+	}
+	enabled := MustCompileJSONPath(`$..[?(@.enabled == true)]`).Query(servers)
+	if len(enabled) != 1 || enabled[0].Path.String() != "$['servers'][0]" {
+		t.Fatalf("unexpected enabled servers:%v", enabled)
+	}
+}
+
+func TestJSONPathSyntax(t *testing.T) {
+	invalid := []string{
+		``, `store`, `$.`, `$..`, `$[`, `$[1`, `$[01]`, `$[-0]`, `$[9007199254740992]`, `$ `,
+		`$['a'`, `$["\q"]`, `$[?@.a == @.*]`, `$[?@.a = 1]`, `$[?1]`, `$[?length(@.*) > 1]`,
+		`$[?count(1) == 1]`, `$[?foo(@)]`, `$[?length(@.a)]`, `$[?match(@.a)]`, `$.a b`,
+	}
+	for _, query := range invalid {
+		var pathErr *JSONPathError
+		if _, err := CompileJSONPath(query); !errors.As(err, &pathErr) {
+			t.Errorf("%q: expect JSONPathError, got:%v", query, err)
+		}
+	}
+
+	value, err := ToValue([]byte(`{"a'b": {"\n": [0, 1, 2, 3, 4, 5]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := Query(value, `$["a'b"]['\n'][1:5:2]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[1].Path.String() != `$['a\'b']['\n'][3]` {
+		t.Fatalf("unexpected nodes:%v", nodes)
+	}
+	if nodes, _ := Query(value, `$..[5:1:-2]`); len(nodes) != 2 || nodes[0].Value != JNumber(5) {
+		t.Fatalf("unexpected nodes:%v", nodes)
+	}
+}
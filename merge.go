@@ -0,0 +1,210 @@
+package hjson
+
+import (
+	"sort"
+	"strconv"
+)
+
+//MergePatch 按RFC 7396把patch合并到target: patch中的对象递归合并,
+//值为null的成员删除target中对应的键, 其他值替换target中的值. target和patch都不会被修改
+func MergePatch(target, patch Value) Value {
+	p, ok := AsObject(patch)
+	if !ok {
+		return Clone(patch)
+	}
+	result, ok := AsObject(Clone(target))
+	if !ok {
+		result = NewObject()
+	}
+	for _, key := range p.Keys() {
+		value := p.values[key]
+		if IsNull(value) {
+			result.Delete(key)
+			continue
+		}
+		old, ok := result.values[key]
+		if !ok {
+			old = JNull{}
+		}
+		result.Set(key, MergePatch(old, value))
+	}
+	return result
+}
+
+//ArrayStrategy DeepMerge合并两个数组的方式
+type ArrayStrategy int
+
+const (
+	//ArrayReplace 用overlay中的数组替换base中的数组
+	ArrayReplace ArrayStrategy = iota
+	//ArrayAppend 把overlay中的元素追加到base中的数组之后
+	ArrayAppend
+	//ArrayMergeByKey 元素是对象时按MergeStrategy.Key字段的值匹配, 匹配的元素递归合并,
+	//其余的元素追加到末尾
+	ArrayMergeByKey
+)
+
+//MergeStrategy 数组的合并策略
+type MergeStrategy struct {
+	Arrays ArrayStrategy
+	//Key ArrayMergeByKey用来匹配元素的字段
+	Key string
+}
+
+//MergeOptions DeepMerge的选项, 零值表示数组整体替换, null作为普通的值
+type MergeOptions struct {
+	//Default 没有在Paths中指定的数组的策略
+	Default MergeStrategy
+	//Paths 按JSON Pointer指定某些位置的数组的策略, 引用标记"*"匹配任意的键或下标,
+	//如"/servers"或"/servers/*/ports". 多个模式匹配时"*"少的优先, 然后按字典序
+	Paths map[string]MergeStrategy
+	//NullDeletes 为true时overlay中值为null的成员删除base中对应的键, 与MergePatch相同
+	NullDeletes bool
+}
+
+//DeepMerge 返回把overlay合并到base之后的结果: 对象递归合并, 数组按opts中的策略合并,
+//其他情况下overlay中的值优先. base和overlay都不会被修改. 合并多层配置时依次调用.
+//opts.Paths中有不合法的JSON Pointer时panic
+func DeepMerge(base, overlay Value, opts MergeOptions) Value {
+	pointers := make([]string, 0, len(opts.Paths))
+	for pointer := range opts.Paths {
+		pointers = append(pointers, pointer)
+	}
+	m := &merger{opts: opts, patterns: compilePatterns(pointers)}
+	return m.merge(nil, base, overlay)
+}
+
+type merger struct {
+	opts     MergeOptions
+	patterns []pathPattern
+}
+
+func (m *merger) merge(path []string, base, overlay Value) Value {
+	if b, ok := AsObject(base); ok {
+		if o, ok := AsObject(overlay); ok {
+			result := Clone(b).(*JObject)
+			for _, key := range o.Keys() {
+				value := o.values[key]
+				if m.opts.NullDeletes && IsNull(value) {
+					result.Delete(key)
+					continue
+				}
+				if old, ok := b.values[key]; ok {
+					result.Set(key, m.merge(append(path, key), old, value))
+				} else {
+					result.Set(key, Clone(value))
+				}
+			}
+			return result
+		}
+	}
+	if b, ok := AsArray(base); ok {
+		if o, ok := AsArray(overlay); ok {
+			return m.mergeArrays(path, b, o)
+		}
+	}
+	return Clone(overlay)
+}
+
+func (m *merger) mergeArrays(path []string, base, overlay *JArray) Value {
+	strategy := m.strategy(path)
+	switch strategy.Arrays {
+	case ArrayAppend:
+		result := Clone(base).(*JArray)
+		result.Append(Clone(overlay).(*JArray).elements...)
+		return result
+	case ArrayMergeByKey:
+		result := Clone(base).(*JArray)
+		for _, elem := range overlay.elements {
+			i := indexByKey(result, elem, strategy.Key)
+			if i < 0 {
+				result.Append(Clone(elem))
+				continue
+			}
+			result.elements[i] = m.merge(append(path[:len(path):len(path)], strconv.Itoa(i)), result.elements[i], elem)
+		}
+		return result
+	}
+	return Clone(overlay)
+}
+
+//indexByKey 返回array中key字段与elem相同的对象的下标, 没有时返回-1
+func indexByKey(array *JArray, elem Value, key string) int {
+	obj, ok := AsObject(elem)
+	if !ok {
+		return -1
+	}
+	want, ok := obj.values[key]
+	if !ok {
+		return -1
+	}
+	for i, v := range array.elements {
+		if o, ok := AsObject(v); ok {
+			if got, ok := o.values[key]; ok && Equal(want, got) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//strategy 返回path处的数组的策略, 见matchPattern
+func (m *merger) strategy(path []string) MergeStrategy {
+	if pointer, ok := matchPattern(m.patterns, path); ok {
+		return m.opts.Paths[pointer]
+	}
+	return m.opts.Default
+}
+
+//pathPattern 编译后的Paths中的一个JSON Pointer, "*"匹配任意的键或下标
+type pathPattern struct {
+	pointer   string
+	tokens    []string
+	wildcards int
+}
+
+//compilePatterns 解析pointers并按"*"的个数从少到多排序, 个数相同时按字典序,
+//使匹配的结果不依赖map的遍历顺序. pointer不合法时panic, 与Index相同
+func compilePatterns(pointers []string) []pathPattern {
+	patterns := make([]pathPattern, len(pointers))
+	for i, pointer := range pointers {
+		tokens, err := ParsePointer(pointer)
+		if err != nil {
+			panic(err)
+		}
+		n := 0
+		for _, token := range tokens {
+			if token == "*" {
+				n++
+			}
+		}
+		patterns[i] = pathPattern{pointer, tokens, n}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		x, y := patterns[i], patterns[j]
+		return x.wildcards < y.wildcards || x.wildcards == y.wildcards && x.pointer < y.pointer
+	})
+	return patterns
+}
+
+//matchPattern 返回与path匹配的第一个模式, 精确匹配总是优先于含有"*"的模式
+func matchPattern(patterns []pathPattern, path []string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchPointer(pattern.tokens, path) {
+			return pattern.pointer, true
+		}
+	}
+	return "", false
+}
+
+func matchPointer(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package hjson
+
+import (
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	//RFC 7396附录A中的例子
+	cases := []struct {
+		target, patch, expect string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		target, _ := ToValue([]byte(tc.target))
+		patch, _ := ToValue([]byte(tc.patch))
+		expect, _ := ToValue([]byte(tc.expect))
+		if got := MergePatch(target, patch); !Equal(expect, got) {
+			t.Errorf("%s + %s: expect:%v got:%v", tc.target, tc.patch, expect, got)
+		}
+		if original, _ := ToValue([]byte(tc.target)); !Equal(original, target) {
+			t.Errorf("%s + %s: target was modified", tc.target, tc.patch)
+		}
+	}
+}
+
+func TestDeepMerge(t *testing.T) {
+	base, _ := ToValue([]byte(`{
+		"name": "app", "debug": false, "tags": ["a"], "plugins": ["x"],
+		"servers": [{"host": "a", "port": 80, "ports": [1]}, {"host": "b", "port": 81}]
+	}`))
+	env, _ := ToValue([]byte(`{
+		"debug": true, "tags": ["b"], "plugins": ["y"],
+		"servers": [{"host": "b", "port": 8081}, {"host": "c", "port": 82}, {"port": 1}]
+	}`))
+	local, _ := ToValue([]byte(`{"name": null, "servers": [{"host": "a", "ports": [2]}]}`))
+	opts := MergeOptions{
+		Paths: map[string]MergeStrategy{
+			"/tags":            {Arrays: ArrayAppend},
+			"/servers":         {Arrays: ArrayMergeByKey, Key: "host"},
+			"/servers/*/ports": {Arrays: ArrayAppend},
+		},
+		NullDeletes: true,
+	}
+	merged := DeepMerge(DeepMerge(base, env, opts), local, opts)
+	expect, _ := ToValue([]byte(`{
+		"debug": true, "tags": ["a", "b"], "plugins": ["y"],
+		"servers": [{"host": "a", "port": 80, "ports": [1, 2]}, {"host": "b", "port": 8081},
+			{"host": "c", "port": 82}, {"port": 1}]
+	}`))
+	if !Equal(expect, merged) {
+		t.Fatalf("expect:%v got:%v", expect, merged)
+	}
+	if name, _ := base.(*JObject).GetString("name"); name != "app" {
+		t.Fatal("expect base to be unchanged")
+	}
+
+	merged = DeepMerge(base, local, MergeOptions{Default: MergeStrategy{Arrays: ArrayAppend}})
+	if !IsNull(merged.(*JObject).values["name"]) {
+		t.Fatalf("expect null to be kept without NullDeletes, got:%v", merged)
+	}
+	if servers, _ := merged.(*JObject).GetArray("servers"); servers.Len() != 3 {
+		t.Fatalf("expect appended servers, got:%v", servers)
+	}
+	if v := DeepMerge(base, JNumber(1), MergeOptions{}); v != JNumber(1) {
+		t.Fatalf("expect overlay scalar to win, got:%v", v)
+	}
+}
+
+func TestMergeOverlappingPatterns(t *testing.T) {
+	base, _ := ToValue([]byte(`{"a": {"ports": [1], "tags": [1]}}`))
+	overlay, _ := ToValue([]byte(`{"a": {"ports": [2], "tags": [2]}}`))
+	//"*"较少的模式优先, 个数相同时取字典序较小的("/*/ports" < "/a/*")
+	opts := MergeOptions{Paths: map[string]MergeStrategy{
+		"/*/*":     {Arrays: ArrayReplace},
+		"/a/*":     {Arrays: ArrayReplace},
+		"/*/ports": {Arrays: ArrayAppend},
+		"/*/tags":  {Arrays: ArrayReplace},
+	}}
+	expect, _ := ToValue([]byte(`{"a": {"ports": [1, 2], "tags": [2]}}`))
+	for i := 0; i < 20; i++ {
+		if got := DeepMerge(base, overlay, opts); !Equal(expect, got) {
+			t.Fatalf("expect:%v got:%v", expect, got)
+		}
+	}
+}
+
+func TestMergeInvalidPattern(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*PointerError); !ok {
+			t.Errorf("expect panic with *PointerError")
+		}
+	}()
+	DeepMerge(NewObject(), NewObject(), MergeOptions{Paths: map[string]MergeStrategy{"servers": {Arrays: ArrayAppend}}})
+}
@@ -4,86 +4,340 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
+	"strings"
 )
 
-//TODO: 两种处理错误的策略，一种是遇到错误立即返回
-//另一种是记录错误，继续解析, 这里打算采用第一种方法，第二种方法比较适合
-//交互的程序编译
+//处理错误有两种策略: 一种是遇到错误立即返回, 另一种是记录错误继续解析.
+//默认采用第一种; Options.Recover打开第二种, 适合编辑器等交互的程序
+
+//Options 解析选项, 零值即默认行为
+type Options struct {
+	//Recover 为true时遇到错误不立即返回, 而是记录错误并跳到下一个同步点
+	//(',' '}' ']' 或换行)继续解析, 出错的值用JNull占位.
+	//此时返回部分解析结果, 错误为包含所有错误的ErrorList
+	Recover bool
+	//ContainerOnly 为true时顶层的值只能是对象或数组(RFC 4627的规定),
+	//默认允许顶层为字符串、数字、true、false、null(RFC 8259)
+	ContainerOnly bool
+	//Dialect 输入的语法, 默认为严格的JSON
+	Dialect Dialect
+	//DuplicateKeys 对象中出现重复键时的处理方式, 默认报错
+	DuplicateKeys DuplicateKeys
+	//Limits 资源限制, 默认不限制
+	Limits Limits
+	//InvalidUTF8 输入中出现非法UTF-8字节时的处理方式, 默认报错
+	InvalidUTF8 InvalidUTF8
+	//DetectEncoding 为true时识别UTF-16和UTF-32的输入并转换成UTF-8.
+	//开头的UTF-8 BOM总是被跳过
+	DetectEncoding bool
+	//SourceMap 不为nil时记录每个值和键在输入中的范围
+	SourceMap *SourceMap
+}
+
+//Limits 解析不可信的输入时的资源限制, 超出时返回LimitError. 0表示不限制
+type Limits struct {
+	//MaxDepth 对象和数组的最大嵌套层数
+	MaxDepth int
+	//MaxBytes 输入的总字节数, 对Decoder是整个输入流
+	MaxBytes int
+	//MaxStringLength 字符串(包括键)字面值的最大字节数
+	MaxStringLength int
+	//MaxNumberLength 数字字面值的最大字节数
+	MaxNumberLength int
+	//MaxObjectMembers 一个对象中键值对的最大个数
+	MaxObjectMembers int
+	//MaxArrayLength 一个数组中元素的最大个数
+	MaxArrayLength int
+}
+
+//DuplicateKeys 对象中出现重复键时的处理方式
+type DuplicateKeys int
+
+const (
+	//DuplicateReject 报错, 错误中包含DuplicateKeyError
+	DuplicateReject DuplicateKeys = iota
+	//DuplicateFirst 保留第一次出现的值
+	DuplicateFirst
+	//DuplicateLast 保留最后一次出现的值
+	DuplicateLast
+	//DuplicateCollect 把所有的值按出现顺序收集到一个JArray中,
+	//便于审查有歧义的输入. 没有重复的键不受影响
+	DuplicateCollect
+)
+
+//keySet 解析一个对象时记录每个键第一次出现的位置,
+//以及DuplicateCollect收集重复值的数组
+type keySet struct {
+	pos   map[string]Position
+	lists map[string]*JArray
+}
 
-//TODO: 优化错误处理
 type parser struct {
 	jscanner *scanner
 	token    int
 	literal  string
+	//字符串记号转义之后的值
+	value string
+	opts  Options
+	errs  ErrorList
+	//正在解析的对象和数组, 保存各自的开始记号
+	stack []int
+	//Hjson的根对象省略了大括号
+	braceless bool
+	//上一个记号的结束位置
+	prevEnd Position
+	//spans为opts.SourceMap, 解析不会保留的重复值时为nil; path是正在解析的值的路径
+	spans *SourceMap
+	path  []string
 }
 
 func NewParser(s string) *parser {
 	return newParser(bytes.NewBufferString(s))
 }
 func newParser(reader io.Reader) *parser {
+	return newParserWithOptions(reader, Options{})
+}
+func newParserWithOptions(reader io.Reader, opts Options) *parser {
+	s := newScanner(reader)
+	s.dialect = opts.Dialect
+	s.limits = opts.Limits
+	s.invalidUTF8 = opts.InvalidUTF8
 	return &parser{
-		jscanner: newScanner(reader),
+		jscanner: s,
+		opts:     opts,
+		spans:    opts.SourceMap,
 	}
 }
+
+//start 获取文档的第一个token. Hjson的根对象可以省略大括号,
+//这需要在读取第一个token之前判断, 因为它决定了第一个token是键还是值
+func (p *parser) start() {
+	p.jscanner.detectEncoding(p.opts.DetectEncoding)
+	if p.opts.Dialect == DialectHjson && p.jscanner.bracelessRoot() {
+		p.braceless = true
+		p.push(tokenLBrace)
+	}
+	p.next()
+}
+
+//parse 解析恰好一个文档, 文档之后只允许出现空白
 func (p *parser) parse() (Value, error) {
-	//获取第一个token
-	token, literal := p.jscanner.nextToken()
-	p.token = token
-	p.literal = literal
-	switch token {
-	case tokenLBrace:
-		return p.parseObject()
-	case tokenLBracket:
-		return p.parseArray()
-	case tokenEOF:
-		return nil, errEOF
-	case tokenInvalid:
-		return nil, p.jscanner.err
+	p.start()
+	value, err := p.parseDocument()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != tokenEOF {
+		err := p.getErr(p.errorf("invalid character after top-level value: %s", p.literal))
+		if err := p.record(err); err != nil {
+			return nil, err
+		}
+	}
+	return value, p.errs.Err()
+}
+
+//parseDocument 从当前记号开始解析一个顶层的值, 不检查之后的内容
+func (p *parser) parseDocument() (Value, error) {
+	var value Value
+	var err error
+	if p.spans != nil {
+		p.spans.reset()
+	}
+	start := p.position()
+	switch {
+	case p.braceless:
+		p.braceless = false
+		obj := NewObject()
+		if err = p.parseMembers(obj, tokenEOF); err == nil {
+			value = obj
+		}
+	case p.token == tokenLBrace:
+		value, err = p.parseObject()
+	case p.token == tokenLBracket:
+		value, err = p.parseArray()
+	case p.token == tokenEOF:
+		err = p.getErr(p.errorf("%s", errEOF))
 	default:
+		if p.opts.ContainerOnly {
+			err = p.getErr(p.errorf(`expected: '{', '[' got: %s`, p.literal))
+		} else {
+			value, err = p.parseValues()
+		}
+	}
+	if err != nil {
+		if err = p.report(err); err != nil {
+			return nil, err
+		}
+	} else if p.spans != nil {
+		p.spans.setValue(nil, Span{start, p.prevEnd})
 	}
-	return nil, fmt.Errorf(`expected: '{', '[' got: %s`, p.literal)
+	return value, nil
 }
 
 func (p *parser) parseObject() (Value, error) {
 	obj := NewObject()
+	if err := p.push(tokenLBrace); err != nil {
+		return nil, err
+	}
 	p.match(tokenLBrace)
-	for {
-		if p.token != tokenString {
+	if err := p.parseMembers(obj, tokenRBrace); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+//parseMembers 解析键值对直到closer, closer为tokenEOF时是省略了大括号的Hjson根对象.
+//返回前弹出obj在stack中的记录
+func (p *parser) parseMembers(obj *JObject, closer int) error {
+	keys := &keySet{pos: make(map[string]Position)}
+	//members 已解析的键值对个数, 重复的键也计算在内
+	for members := 0; p.token != closer && p.token != tokenEOF; members++ {
+		if max := p.opts.Limits.MaxObjectMembers; max > 0 && members >= max {
+			return p.limitError("MaxObjectMembers", max)
+		}
+		err := p.parseMember(obj, keys)
+		failed := err != nil
+		if failed {
+			if err = p.report(err); err != nil {
+				return err
+			}
+		}
+		if err := p.skipStray(closer); err != nil {
+			return err
+		}
+		if p.match(tokenComma) {
+			if err := p.trailingComma(closer); err != nil {
+				return err
+			}
+			continue
+		}
+		if p.token == tokenRBrace || p.token == tokenRBracket || p.token == tokenEOF {
 			break
 		}
-		key := p.literal
-		if _, ok := obj.values[key]; ok {
-			return nil, fmt.Errorf("repeated key:%s in object", key)
+		if p.newlineSeparator() {
+			continue
 		}
-		p.match(tokenString)
-		if !p.match(tokenColon) {
-			return nil, p.getErr(fmt.Errorf(`expect: ':' got:%s`, p.literal))
+		//缺少逗号, 恢复模式下当作有逗号继续解析下一个键值对
+		if !failed {
+			if err := p.record(p.getErr(p.errorf(`expect: ',' or '}' got:%s`, p.literal))); err != nil {
+				return err
+			}
+		}
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	if !p.match(closer) {
+		err := p.errorf("expect: key-value pair or '}' got:%s", p.literal)
+		if p.token == tokenEOF {
+			err = p.errorf("%s", errEOF)
+		}
+		if err := p.report(err); err != nil {
+			return err
 		}
-		value, err := p.parseValues()
+	}
+	return nil
+}
+
+//parseMember 解析一个键值对并加入obj, 值解析失败时以JNull占位.
+//重复的键按Options.DuplicateKeys处理
+func (p *parser) parseMember(obj *JObject, keys *keySet) error {
+	if p.token != tokenString {
+		return p.getErr(p.errorf("expect: key got:%s", p.literal))
+	}
+	key, pos := p.value, p.position()
+	p.match(tokenString)
+	keySpan := Span{pos, p.prevEnd}
+	if !p.match(tokenColon) {
+		return p.getErr(p.errorf(`expect: ':' got:%s`, p.literal))
+	}
+	first, repeated := keys.pos[key]
+	if p.spans != nil {
+		defer p.enterMember(key, keySpan, repeated, keys)()
+	}
+	value, err := p.parseValues()
+	if !repeated {
+		keys.pos[key] = pos
 		if err != nil {
-			return nil, err
+			value = JNull{}
 		}
 		obj.values[key] = value
-		if p.token != tokenComma {
-			break
+	}
+	if err != nil || !repeated {
+		return err
+	}
+	switch p.opts.DuplicateKeys {
+	case DuplicateFirst:
+	case DuplicateLast:
+		obj.values[key] = value
+	case DuplicateCollect:
+		list, ok := keys.lists[key]
+		if !ok {
+			if keys.lists == nil {
+				keys.lists = make(map[string]*JArray)
+			}
+			list = NewArray()
+			list.addValue(obj.values[key])
+			keys.lists[key] = list
+			obj.values[key] = list
+		}
+		//收集重复值的数组同样受MaxArrayLength限制
+		if max := p.opts.Limits.MaxArrayLength; max > 0 && len(list.elements) >= max {
+			return &LimitError{Limit: "MaxArrayLength", Max: max, Position: pos}
 		}
-		p.match(tokenComma)
+		list.addValue(value)
+	default:
+		dup := &DuplicateKeyError{Key: key, First: first, Second: pos}
+		return &SyntaxError{Msg: dup.Error(), Position: pos, Err: dup}
+	}
+	return nil
+}
+
+//enterMember 在解析键key的值之前更新路径和SourceMap, 返回解析完之后恢复状态的函数.
+//重复的键只记录最终保留的值
+func (p *parser) enterMember(key string, span Span, repeated bool, keys *keySet) func() {
+	n, spans := len(p.path), p.spans
+	p.path = append(p.path, key)
+	switch {
+	case !repeated:
+		spans.setKey(p.path, span)
+	case p.opts.DuplicateKeys == DuplicateLast:
+		spans.remove(p.path)
+		spans.setKey(p.path, span)
+	case p.opts.DuplicateKeys == DuplicateCollect:
+		i := 1
+		if list, ok := keys.lists[key]; ok {
+			i = list.Len()
+		} else {
+			spans.move(p.path, indexPath(p.path, 0))
+		}
+		p.path = indexPath(p.path, i)
+	default:
+		p.spans = nil
 	}
-	if !p.match(tokenRBrace) {
-		return nil, p.getErr(fmt.Errorf("expect: key-value pair or '}' got:%s", p.literal))
+	return func() {
+		p.path, p.spans = p.path[:n], spans
 	}
-	return obj, nil
 }
 
+//parseValues 解析一个值, 启用了SourceMap时记录它的范围
 func (p *parser) parseValues() (Value, error) {
+	start := p.position()
+	value, err := p.parseValue()
+	if err == nil && p.spans != nil {
+		p.spans.setValue(p.path, Span{start, p.prevEnd})
+	}
+	return value, err
+}
+
+func (p *parser) parseValue() (Value, error) {
 	switch p.token {
 	case tokenLBrace:
 		return p.parseObject()
 	case tokenLBracket:
 		return p.parseArray()
 	case tokenString:
-		value := p.literal
+		value := p.value
 		p.match(tokenString)
 		return JString(value), nil
 	case tokenNumber:
@@ -98,70 +352,168 @@ func (p *parser) parseValues() (Value, error) {
 		p.match(tokenFalse)
 		return JBool(false), nil
 	case tokenEOF:
-		return nil, errEOF
+		return nil, p.errorf("%s", errEOF)
 	}
-	return nil, p.getErr(fmt.Errorf(`expect: STRING, NUMBER, TRUE, FALSE, NULL, {, [`))
+	return nil, p.getErr(p.errorf(`expect: STRING, NUMBER, TRUE, FALSE, NULL, {, [ got:%s`, p.literal))
 }
 
 func (p *parser) parseArray() (Value, error) {
+	if err := p.push(tokenLBracket); err != nil {
+		return nil, err
+	}
 	p.match(tokenLBracket)
 	array := NewArray()
-	for {
-		switch p.token {
-		case tokenNumber:
-			v, _ := p.parseNumber()
-			array.addValue(v)
-		case tokenString:
-			array.addValue(JString(p.literal))
-			p.match(tokenString)
-		case tokenNull:
-			array.addValue(JNull{})
-			p.match(tokenNull)
-		case tokenTrue:
-			array.addValue(JBool(true))
-			p.match(tokenTrue)
-		case tokenFalse:
-			array.addValue(JBool(false))
-			p.match(tokenFalse)
-		case tokenLBrace:
-			v, err := p.parseObject()
-			if err != nil {
+	for p.token != tokenRBracket && p.token != tokenEOF {
+		if max := p.opts.Limits.MaxArrayLength; max > 0 && len(array.elements) >= max {
+			return nil, p.limitError("MaxArrayLength", max)
+		}
+		if p.spans != nil {
+			p.path = indexPath(p.path, len(array.elements))
+		}
+		v, err := p.parseValues()
+		if p.spans != nil {
+			p.path = p.path[:len(p.path)-1]
+		}
+		failed := err != nil
+		if failed {
+			if err = p.report(err); err != nil {
 				return nil, err
 			}
-			array.addValue(v)
-		case tokenLBracket:
-			v, err := p.parseArray()
-			if err != nil {
+			v = JNull{}
+		}
+		array.addValue(v)
+		if err := p.skipStray(tokenRBracket); err != nil {
+			return nil, err
+		}
+		if p.match(tokenComma) {
+			if err := p.trailingComma(tokenRBracket); err != nil {
 				return nil, err
 			}
-			array.addValue(v)
-		case tokenEOF:
-			return nil, errEOF
+			continue
 		}
-		if p.token != tokenComma {
+		if p.token == tokenRBracket || p.token == tokenRBrace || p.token == tokenEOF {
 			break
 		}
-		p.match(tokenComma)
+		if p.newlineSeparator() {
+			continue
+		}
+		if !failed {
+			if err := p.record(p.getErr(p.errorf(`expect: ',' or ']' got:%s`, p.literal))); err != nil {
+				return nil, err
+			}
+		}
 	}
+	p.stack = p.stack[:len(p.stack)-1]
 	if !p.match(tokenRBracket) {
-		return nil, fmt.Errorf("expected:], got: %s", p.literal)
+		err := p.errorf("expected:], got: %s", p.literal)
+		if p.token == tokenEOF {
+			err = p.errorf("%s", errEOF)
+		}
+		if err := p.report(err); err != nil {
+			return nil, err
+		}
 	}
 	return array, nil
 }
 
+//parseNumber 整数解析为JNumber, 带小数或指数以及超出int64范围的解析为JFloat.
+//JSON5的十六进制数字是整数, Infinity和NaN是JFloat
 func (p *parser) parseNumber() (Value, error) {
-	v, _ := strconv.Atoi(p.literal)
+	lit := p.literal
+	digits := strings.TrimLeft(lit, "+-")
+	hex := strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X")
+	if hex || !strings.ContainsAny(lit, ".eEIN") {
+		if v, err := strconv.ParseInt(lit, 0, 64); err == nil {
+			p.match(tokenNumber)
+			return JNumber(v), nil
+		}
+	}
+	var v float64
+	var err error
+	switch {
+	case hex:
+		var u uint64
+		u, err = strconv.ParseUint(digits[2:], 16, 64)
+		v = float64(u)
+		if lit[0] == '-' {
+			v = -v
+		}
+	case digits == "NaN":
+		v = math.NaN()
+	default:
+		v, err = strconv.ParseFloat(lit, 64)
+	}
+	if err != nil {
+		return nil, p.errorf("number out of range: %s", lit)
+	}
 	p.match(tokenNumber)
-	return JNumber(v), nil
+	return JFloat(v), nil
+}
+
+//push 开始解析对象或数组, 检查嵌套层数
+func (p *parser) push(token int) error {
+	if max := p.opts.Limits.MaxDepth; max > 0 && len(p.stack) >= max {
+		return p.limitError("MaxDepth", max)
+	}
+	p.stack = append(p.stack, token)
+	return nil
+}
+
+func (p *parser) limitError(name string, max int) *LimitError {
+	return &LimitError{Limit: name, Max: max, Position: p.position()}
+}
+
+//trailingComma 检查逗号之后是否紧跟着结束符closer, 只有严格的JSON不允许
+func (p *parser) trailingComma(closer int) error {
+	if p.token != closer || p.opts.Dialect != DialectJSON {
+		return nil
+	}
+	return p.record(p.errorf("unexpected %s after ','", tokenTable[closer]))
+}
+
+//skipStray 恢复模式下跳过当前层多余的'}'或']', 它们既不是closer, 也不能结束外层的对象或数组.
+//每个被跳过的记号记录一个错误, 之后继续解析同一层的成员
+func (p *parser) skipStray(closer int) error {
+	for p.opts.Recover && p.token != closer && (p.token == tokenRBrace || p.token == tokenRBracket) {
+		opener := tokenLBrace
+		if p.token == tokenRBracket {
+			opener = tokenLBracket
+		}
+		for _, t := range p.stack[:len(p.stack)-1] {
+			if t == opener {
+				return nil
+			}
+		}
+		if err := p.record(p.getErr(p.errorf("unexpected %s", p.literal))); err != nil {
+			return err
+		}
+		p.next()
+	}
+	return nil
 }
-func (p *parser) parseString() (Value, error) {
 
-	return nil, nil
+//newlineSeparator Hjson中换行可以代替逗号
+func (p *parser) newlineSeparator() bool {
+	return p.opts.Dialect == DialectHjson && p.jscanner.newline
+}
+
+//next 读取下一个token. ':'之后以及数组中是值, 对象中其他位置是键
+func (p *parser) next() {
+	mode := modeValue
+	if n := len(p.stack); p.token != tokenColon && n > 0 && p.stack[n-1] == tokenLBrace {
+		mode = modeKey
+	}
+	p.jscanner.mode = mode
+	if p.token != tokenEOF {
+		p.prevEnd = p.jscanner.tokEnd
+	}
+	p.token, p.literal = p.jscanner.nextToken()
+	p.value = p.jscanner.value
 }
 
 func (p *parser) match(token int) bool {
 	if p.token == token {
-		p.token, p.literal = p.jscanner.nextToken()
+		p.next()
 		return true
 	}
 	return false
@@ -173,3 +525,73 @@ func (p *parser) getErr(err error) error {
 	}
 	return err
 }
+
+//errorf 生成位于当前记号处的语法错误
+func (p *parser) errorf(format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: p.position(),
+	}
+}
+
+//position 当前记号的起始位置
+func (p *parser) position() Position {
+	s := p.jscanner
+	return Position{s.tokLine, s.tokPos, s.tokOffset}
+}
+
+//report 非恢复模式下原样返回错误; 恢复模式下记录错误,
+//跳到同步点后返回nil
+func (p *parser) report(err error) error {
+	if err = p.record(err); err != nil {
+		return err
+	}
+	p.sync()
+	return nil
+}
+
+//record 与report相同, 但不跳过任何记号.
+//同一位置只记录第一个错误, 避免连锁报错
+func (p *parser) record(err error) error {
+	if fatal := p.jscanner.fatal; fatal != nil {
+		return fatal
+	}
+	if _, ok := err.(*LimitError); ok || !p.opts.Recover {
+		return err
+	}
+	se, ok := err.(*SyntaxError)
+	if !ok {
+		se = p.errorf("%s", err)
+	}
+	if n := len(p.errs); n == 0 || p.errs[n-1].Offset != se.Offset {
+		p.errs = append(p.errs, se)
+	}
+	return nil
+}
+
+//sync 跳过记号直到同一层的',' '}' ']', 换行后的第一个记号或EOF.
+//出错的记号本身总会被跳过(除非它就是同步点), 嵌套的对象和数组整体跳过
+func (p *parser) sync() {
+	depth := 0
+	for {
+		switch p.token {
+		case tokenEOF:
+			return
+		case tokenLBrace, tokenLBracket:
+			depth++
+		case tokenRBrace, tokenRBracket:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case tokenComma:
+			if depth == 0 {
+				return
+			}
+		}
+		p.next()
+		if depth == 0 && p.jscanner.newline {
+			return
+		}
+	}
+}
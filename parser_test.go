@@ -3,7 +3,11 @@ package hjson
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -120,15 +124,15 @@ func check(expect Value, got Value) error {
 		return fmt.Errorf("expect:%v got:%v", expect, got)
 	}
 	switch got.Type() {
-	case typeString:
+	case TypeString:
 		if expect.String() != got.String() {
 			return fmt.Errorf("expect:%v got:%v", expect, got)
 		}
-	case typeBool:
+	case TypeBool:
 		if expect.String() != got.String() {
 			return fmt.Errorf("expect:%v got:%v", expect, got)
 		}
-	case typeArray:
+	case TypeArray:
 		expectedArray := expect.(*JArray)
 		gotArray := got.(*JArray)
 		if len(expectedArray.elements) != len(gotArray.elements) {
@@ -140,7 +144,7 @@ func check(expect Value, got Value) error {
 			}
 		}
 
-	case typeObj:
+	case TypeObject:
 		expectedObj := expect.(*JObject)
 		gotObj := got.(*JObject)
 		values := expectedObj.values
@@ -158,7 +162,7 @@ func check(expect Value, got Value) error {
 			}
 		}
 
-	case typeNumber:
+	case TypeNumber:
 		if expect.String() != got.String() {
 			return fmt.Errorf("expect:%v got:%v", expect, got)
 		}
@@ -169,7 +173,6 @@ func check(expect Value, got Value) error {
 func TestInvalidJson(t *testing.T) {
 	jsons := []string{
 		``,
-		`1`,
 		`{"key"}`,
 		`{key:123}`,
 		`{"key":123df}`,
@@ -192,6 +195,214 @@ func TestInvalidJson(t *testing.T) {
 	}
 }
 
+func TestDuplicateKeys(t *testing.T) {
+	input := `{"a":1, "b":true,
+		"a":[2], "a":3}`
+	testCases := []struct {
+		policy DuplicateKeys
+		value  Value
+	}{
+		{DuplicateFirst, JNumber(1)},
+		{DuplicateLast, JNumber(3)},
+		{DuplicateCollect, &JArray{elements: []Value{JNumber(1), &JArray{elements: []Value{JNumber(2)}}, JNumber(3)}}},
+	}
+	for i, tc := range testCases {
+		value, err := ToValueWithOptions([]byte(input), Options{DuplicateKeys: tc.policy})
+		if err != nil {
+			t.Fatalf("case:%d %v", i, err)
+		}
+		expect := &JObject{values: map[string]Value{"a": tc.value, "b": JBool(true)}}
+		if err := check(expect, value); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+	}
+
+	_, err := ToValue([]byte(input))
+	var dup *DuplicateKeyError
+	if !errors.As(err, &dup) {
+		t.Fatalf("expect DuplicateKeyError, got:%v", err)
+	}
+	if dup.Key != "a" || dup.First != (Position{1, 2, 1}) || dup.Second != (Position{2, 3, 20}) {
+		t.Fatalf("unexpected error:%#v", dup)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		json   string
+		limits Limits
+		limit  string
+	}{
+		{strings.Repeat("[", 100000) + strings.Repeat("]", 100000), Limits{MaxDepth: 64}, "MaxDepth"},
+		{`{"a":{"b":[1]}}`, Limits{MaxDepth: 2}, "MaxDepth"},
+		{`[1, 2, 3]`, Limits{MaxBytes: 5}, "MaxBytes"},
+		{`["` + strings.Repeat("x", 1000) + `"]`, Limits{MaxStringLength: 100}, "MaxStringLength"},
+		{`{"` + strings.Repeat("k", 1000) + `":1}`, Limits{MaxStringLength: 100}, "MaxStringLength"},
+		{`[` + strings.Repeat("9", 1000) + `]`, Limits{MaxNumberLength: 100}, "MaxNumberLength"},
+		{`{"a":1, "b":2, "c":3}`, Limits{MaxObjectMembers: 2}, "MaxObjectMembers"},
+		{`[1, 2, 3]`, Limits{MaxArrayLength: 2}, "MaxArrayLength"},
+	}
+	for i, tc := range testCases {
+		for _, recover := range []bool{false, true} {
+			_, err := ToValueWithOptions([]byte(tc.json), Options{Limits: tc.limits, Recover: recover})
+			limitErr, ok := err.(*LimitError)
+			if !ok {
+				t.Fatalf("case:%d recover:%v expect LimitError, got:%v", i, recover, err)
+			}
+			if limitErr.Limit != tc.limit {
+				t.Fatalf("case:%d expect %s, got:%v", i, tc.limit, limitErr)
+			}
+		}
+	}
+
+	//重复的键也计入MaxObjectMembers, DuplicateCollect收集的数组受MaxArrayLength限制
+	dups := `{"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1,"a":1}`
+	duplicateCases := []struct {
+		mode   DuplicateKeys
+		limits Limits
+		limit  string
+	}{
+		{DuplicateFirst, Limits{MaxObjectMembers: 2}, "MaxObjectMembers"},
+		{DuplicateLast, Limits{MaxObjectMembers: 2}, "MaxObjectMembers"},
+		{DuplicateCollect, Limits{MaxObjectMembers: 2, MaxArrayLength: 2}, "MaxObjectMembers"},
+		{DuplicateCollect, Limits{MaxArrayLength: 2}, "MaxArrayLength"},
+	}
+	for i, tc := range duplicateCases {
+		for _, recover := range []bool{false, true} {
+			_, err := ToValueWithOptions([]byte(dups), Options{Limits: tc.limits, DuplicateKeys: tc.mode, Recover: recover})
+			if limitErr, ok := err.(*LimitError); !ok || limitErr.Limit != tc.limit {
+				t.Fatalf("duplicate case:%d recover:%v expect %s, got:%v", i, recover, tc.limit, err)
+			}
+		}
+	}
+
+	limits := Limits{MaxDepth: 3, MaxBytes: 100, MaxStringLength: 5, MaxNumberLength: 3, MaxObjectMembers: 2, MaxArrayLength: 3}
+	if _, err := ToValueWithOptions([]byte(`{"a":[1, [2], "xyz"], "b":123}`), Options{Limits: limits}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScalarRoot(t *testing.T) {
+	testCases := []struct {
+		json  string
+		value Value
+	}{
+		{`"text"`, JString("text")},
+		{` 42 `, JNumber(42)},
+		{`-1.5`, JFloat(-1.5)},
+		{`null`, JNull{}},
+		{"true\n", JBool(true)},
+		{`false`, JBool(false)},
+	}
+	for i, tc := range testCases {
+		value, err := ToValue([]byte(tc.json))
+		if err != nil {
+			t.Fatalf("case:%d %v", i, err)
+		}
+		if err := check(tc.value, value); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+		if _, err := ToValueWithOptions([]byte(tc.json), Options{ContainerOnly: true}); err == nil {
+			t.Fatalf("case:%d expect err with ContainerOnly, got nil", i)
+		}
+	}
+	for i, j := range []string{`1 2`, `"a" "b"`, `nul`, `True`} {
+		if _, err := ToValue([]byte(j)); err == nil {
+			t.Fatalf("case:%d expect err, got nil", i)
+		}
+	}
+}
+
+//TestConformance 运行testdata下的一致性测试用例.
+//JSONTestSuite: y_必须接受, n_必须拒绝, i_由实现决定(只要求不panic);
+//hjson: fail开头的必须拒绝, 其他的必须接受, .hjson文件按Hjson解析,
+//并与同名的_result.json比较
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob("testdata/*/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no conformance test cases")
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		if strings.HasSuffix(name, "_result.json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{}
+		if filepath.Ext(name) == ".hjson" {
+			opts.Dialect = DialectHjson
+		}
+		value, err := ToValueWithOptions(data, opts)
+		switch {
+		case strings.HasPrefix(name, "i_"):
+		case strings.HasPrefix(name, "n_"), strings.HasPrefix(name, "fail"):
+			if err == nil {
+				t.Errorf("%s: expect err, got nil", file)
+			}
+		case err != nil:
+			t.Errorf("%s: expect ok, got:%v", file, err)
+		case opts.Dialect == DialectHjson:
+			result, err := ioutil.ReadFile(strings.TrimSuffix(file, "_test.hjson") + "_result.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect, err := ToValue(result)
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			if err := check(expect, value); err != nil {
+				t.Errorf("%s: %v", file, err)
+			}
+		}
+	}
+}
+
+func TestTrailing(t *testing.T) {
+	jsons := []string{
+		`{"a":1} junk`,
+		`[1 2]`,
+		`[x]`,
+		`[1,]`,
+		`{"a":1,}`,
+		`{"a":1}{"b":2}`,
+	}
+	for i, j := range jsons {
+		if _, err := ToValue([]byte(j)); err == nil {
+			t.Fatalf("case:%d expect err, got nil", i)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	testCases := []struct {
+		json  string
+		value Value
+	}{
+		{`[0, -12, 9223372036854775807]`, &JArray{elements: []Value{JNumber(0), JNumber(-12), JNumber(9223372036854775807)}}},
+		{`[1.5, -0.25e2, 1E3, 9223372036854775808]`, &JArray{elements: []Value{JFloat(1.5), JFloat(-25), JFloat(1000), JFloat(9223372036854775808)}}},
+	}
+	for i, tc := range testCases {
+		value, err := ToValue([]byte(tc.json))
+		if err != nil {
+			t.Fatalf("case:%d %v", i, err)
+		}
+		if err := check(tc.value, value); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+		for j, e := range value.(*JArray).elements {
+			if _, ok := e.(JFloat); ok != (i == 1) {
+				t.Fatalf("case:%d element:%d unexpected type %T", i, j, e)
+			}
+		}
+	}
+}
+
 /*
 func TestJson(t *testing.T) {
 	j := `{"key":12e+-1dddd`
@@ -208,3 +419,130 @@ func TestJson(t *testing.T) {
 	}
 }
 */
+
+func TestRecover(t *testing.T) {
+	testCases := []struct {
+		json  string
+		value Value
+		errs  []int //出错的行号
+	}{
+		{
+			json: `{"a":1, "b":x, "c":3}`,
+			value: &JObject{
+				values: map[string]Value{
+					"a": JNumber(1),
+					"b": JNull{},
+					"c": JNumber(3),
+				},
+			},
+			errs: []int{1},
+		},
+		{
+			json: `{"a":1 "b":2,
+				"c":[1, @, 3],
+				"d":"k\h",
+				"e":true}`,
+			value: &JObject{
+				values: map[string]Value{
+					"a": JNumber(1),
+					"b": JNumber(2),
+					"c": &JArray{
+						elements: []Value{
+							JNumber(1),
+							JNull{},
+							JNumber(3),
+						},
+					},
+					"d": JNull{},
+					"e": JBool(true),
+				},
+			},
+			errs: []int{1, 2, 3},
+		},
+		{
+			json: `[{"a":1, "a":2}, [1, 2}, 3]`,
+			value: &JArray{
+				elements: []Value{
+					&JObject{
+						values: map[string]Value{
+							"a": JNumber(1),
+						},
+					},
+					&JArray{
+						elements: []Value{
+							JNumber(1),
+							JNumber(2),
+							JNumber(3),
+						},
+					},
+				},
+			},
+			errs: []int{1, 1, 1},
+		},
+		{
+			json: `{"a":[1, 2}, "b":3}`,
+			value: &JObject{
+				values: map[string]Value{
+					"a": &JArray{
+						elements: []Value{
+							JNumber(1),
+							JNumber(2),
+						},
+					},
+				},
+			},
+			errs: []int{1, 1},
+		},
+		{
+			json: `{"a":{"b":1]}, "c":2}`,
+			value: &JObject{
+				values: map[string]Value{
+					"a": &JObject{
+						values: map[string]Value{
+							"b": JNumber(1),
+						},
+					},
+					"c": JNumber(2),
+				},
+			},
+			errs: []int{1},
+		},
+		{
+			json: `{"a":[1, 2`,
+			value: &JObject{
+				values: map[string]Value{
+					"a": &JArray{
+						elements: []Value{
+							JNumber(1),
+							JNumber(2),
+						},
+					},
+				},
+			},
+			errs: []int{1},
+		},
+	}
+	for i, tc := range testCases {
+		value, err := ToValueWithOptions([]byte(tc.json), Options{Recover: true})
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Fatalf("case:%d expect ErrorList, got:%v", i, err)
+		}
+		if len(errs) != len(tc.errs) {
+			t.Fatalf("case:%d expect %d errors, got:%v", i, len(tc.errs), errs)
+		}
+		for j, e := range errs {
+			if e.Line != tc.errs[j] {
+				t.Fatalf("case:%d expect error at line %d, got:%v", i, tc.errs[j], e)
+			}
+		}
+		if err := check(tc.value, value); err != nil {
+			t.Fatalf("case:%d %s", i, err)
+		}
+	}
+
+	value, err := ToValueWithOptions([]byte(`{"a":[1, 2]}`), Options{Recover: true})
+	if err != nil || value == nil {
+		t.Fatalf("expect no error, got:%v", err)
+	}
+}
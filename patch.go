@@ -0,0 +1,319 @@
+package hjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//PatchOperation RFC 6902 JSON Patch中的一个操作
+type PatchOperation struct {
+	//Op add, remove, replace, move, copy或test
+	Op   string
+	Path string
+	//From move和copy的源位置
+	From string
+	//Value add, replace和test的值
+	Value Value
+}
+
+//Patch RFC 6902 JSON Patch, 按顺序执行的操作列表
+type Patch []PatchOperation
+
+//PatchError 执行或解析Patch中第Index个操作时出错
+type PatchError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+//ParsePatch 解析JSON格式的Patch文档
+func ParsePatch(data []byte) (Patch, error) {
+	v, err := ToValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return DecodePatch(v)
+}
+
+//DecodePatch 把已经解析的Patch文档转换为Patch, 并检查每个操作的成员
+func DecodePatch(v Value) (Patch, error) {
+	array, ok := AsArray(v)
+	if !ok {
+		return nil, fmt.Errorf("patch must be an array, got %s", typeName(v))
+	}
+	patch := make(Patch, 0, len(array.elements))
+	for i, elem := range array.elements {
+		obj, ok := AsObject(elem)
+		if !ok {
+			return nil, &PatchError{Index: i, Err: fmt.Errorf("operation must be an object, got %s", typeName(elem))}
+		}
+		op, err := obj.GetString("op")
+		if err != nil {
+			return nil, &PatchError{Index: i, Err: err}
+		}
+		var operation PatchOperation
+		operation.Op = op
+		if operation.Path, err = obj.GetString("path"); err != nil {
+			return nil, &PatchError{Index: i, Op: op, Err: err}
+		}
+		switch op {
+		case "add", "replace", "test":
+			value, ok := obj.Get("value")
+			if !ok {
+				return nil, &PatchError{Index: i, Op: op, Err: fmt.Errorf("missing value")}
+			}
+			operation.Value = value
+		case "move", "copy":
+			if operation.From, err = obj.GetString("from"); err != nil {
+				return nil, &PatchError{Index: i, Op: op, Err: err}
+			}
+		case "remove":
+		default:
+			return nil, &PatchError{Index: i, Op: op, Err: fmt.Errorf("unknown operation")}
+		}
+		patch = append(patch, operation)
+	}
+	return patch, nil
+}
+
+//ToValue 把Patch转换为JSON Patch文档
+func (p Patch) ToValue() Value {
+	array := NewArray()
+	for _, operation := range p {
+		obj := NewObject()
+		obj.Set("op", JString(operation.Op))
+		obj.Set("path", JString(operation.Path))
+		switch operation.Op {
+		case "add", "replace", "test":
+			obj.Set("value", Clone(operation.Value))
+		case "move", "copy":
+			obj.Set("from", JString(operation.From))
+		}
+		array.Append(obj)
+	}
+	return array
+}
+
+//Apply 对doc的副本依次执行所有操作并返回结果. 任何一个操作失败时返回PatchError,
+//doc在任何情况下都不会被修改
+func (p Patch) Apply(doc Value) (Value, error) {
+	doc = Clone(doc)
+	for i, operation := range p {
+		var err error
+		if doc, err = operation.apply(doc); err != nil {
+			return nil, &PatchError{Index: i, Op: operation.Op, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func (o PatchOperation) apply(doc Value) (Value, error) {
+	switch o.Op {
+	case "add":
+		return Add(doc, o.Path, Clone(o.Value))
+	case "remove":
+		_, err := Remove(doc, o.Path)
+		return doc, err
+	case "replace":
+		if _, err := Get(doc, o.Path); err != nil {
+			return nil, err
+		}
+		return Set(doc, o.Path, Clone(o.Value))
+	case "move":
+		if o.From == o.Path {
+			_, err := Get(doc, o.From)
+			return doc, err
+		}
+		if strings.HasPrefix(o.Path, o.From+"/") {
+			return nil, fmt.Errorf("cannot move %q into its own child %q", o.From, o.Path)
+		}
+		v, err := Remove(doc, o.From)
+		if err != nil {
+			return nil, err
+		}
+		return Add(doc, o.Path, v)
+	case "copy":
+		v, err := Get(doc, o.From)
+		if err != nil {
+			return nil, err
+		}
+		return Add(doc, o.Path, Clone(v))
+	case "test":
+		v, err := Get(doc, o.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !EqualWithOptions(v, o.Value, EqualOptions{NumericEqual: true}) {
+			return nil, fmt.Errorf("test failed: %q is %v, not %v", o.Path, v, o.Value)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation")
+}
+
+//CreatePatch 生成把from变为to的Patch. 对象逐个键比较,
+//数组按最小编辑距离对齐之后生成add, remove以及对元素的递归修改, 很大的数组去掉相同的首尾之后按位置对齐
+func CreatePatch(from, to Value) Patch {
+	return diffPatch(nil, nil, from, to)
+}
+
+func diffPatch(patch Patch, path []string, a, b Value) Patch {
+	if EqualWithOptions(a, b, EqualOptions{NumericEqual: true}) {
+		return patch
+	}
+	x, xok := AsObject(a)
+	y, yok := AsObject(b)
+	if xok && yok {
+		for _, key := range x.Keys() {
+			if w, ok := y.values[key]; ok {
+				patch = diffPatch(patch, append(path, key), x.values[key], w)
+			} else {
+				patch = append(patch, PatchOperation{Op: "remove", Path: FormatPointer(append(path, key))})
+			}
+		}
+		for _, key := range y.Keys() {
+			if !x.Has(key) {
+				patch = append(patch, PatchOperation{Op: "add", Path: FormatPointer(append(path, key)), Value: Clone(y.values[key])})
+			}
+		}
+		return patch
+	}
+	s, sok := AsArray(a)
+	t, tok := AsArray(b)
+	if sok && tok {
+		return diffArrays(patch, path, s.elements, t.elements)
+	}
+	return append(patch, PatchOperation{Op: "replace", Path: FormatPointer(path), Value: Clone(b)})
+}
+
+//diffArrays 按editScript对齐两个数组, 然后从前往后生成操作, 生成过程中index是元素在当前数组中的下标
+func diffArrays(patch Patch, path []string, a, b []Value) Patch {
+	index := 0
+	i, j := 0, 0
+	for _, op := range editScript(a, b, false) {
+		elem := append(path[:len(path):len(path)], strconv.Itoa(index))
+		switch op {
+		case editKeep:
+			i, j, index = i+1, j+1, index+1
+		case editReplace:
+			patch = diffPatch(patch, elem, a[i], b[j])
+			i, j, index = i+1, j+1, index+1
+		case editRemove:
+			patch = append(patch, PatchOperation{Op: "remove", Path: FormatPointer(elem)})
+			i++
+		case editInsert:
+			if i == len(a) {
+				elem[len(elem)-1] = "-"
+			}
+			patch = append(patch, PatchOperation{Op: "add", Path: FormatPointer(elem), Value: Clone(b[j])})
+			j, index = j+1, index+1
+		}
+	}
+	return patch
+}
+
+//editOp 编辑脚本中的一步
+type editOp int
+
+const (
+	editKeep editOp = iota
+	editReplace
+	editRemove
+	editInsert
+)
+
+//maxEditCells 编辑距离表最多的格数, 超过时按位置对齐
+const maxEditCells = 1 << 20
+
+//editScript 用编辑距离(删除、插入和替换的代价都是1)对齐a和b, 返回从前往后的编辑步骤.
+//相等的前缀和后缀直接保留; 剩下的部分太大时按位置替换, 多出的元素删除或插入.
+//代价相同时先保留, 然后removeFirst为true时先删除再替换, 否则先替换再删除, 最后插入
+func editScript(a, b []Value, removeFirst bool) []editOp {
+	equal := func(x, y Value) bool { return EqualWithOptions(x, y, EqualOptions{NumericEqual: true}) }
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+	ops := make([]editOp, prefix, len(a)+len(b))
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(a), len(b)
+	if (n+1)*(m+1) > maxEditCells {
+		for k := 0; k < n || k < m; k++ {
+			switch {
+			case k >= m:
+				ops = append(ops, editRemove)
+			case k >= n:
+				ops = append(ops, editInsert)
+			case equal(a[k], b[k]):
+				ops = append(ops, editKeep)
+			default:
+				ops = append(ops, editReplace)
+			}
+		}
+	} else {
+		//cost[i*(m+1)+j]是把a[i:]变为b[j:]的代价
+		w := m + 1
+		cost := make([]int, (n+1)*w)
+		same := make([]bool, n*m)
+		for i := n; i >= 0; i-- {
+			for j := m; j >= 0; j-- {
+				switch {
+				case i == n:
+					cost[i*w+j] = m - j
+				case j == m:
+					cost[i*w+j] = n - i
+				case equal(a[i], b[j]):
+					same[i*m+j] = true
+					cost[i*w+j] = cost[(i+1)*w+j+1]
+				default:
+					cost[i*w+j] = 1 + min3(cost[(i+1)*w+j+1], cost[(i+1)*w+j], cost[i*w+j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < n || j < m; {
+			replace := i < n && j < m && cost[i*w+j] == 1+cost[(i+1)*w+j+1]
+			remove := i < n && cost[i*w+j] == 1+cost[(i+1)*w+j]
+			switch {
+			case i < n && j < m && same[i*m+j]:
+				ops = append(ops, editKeep)
+				i, j = i+1, j+1
+			case replace && !(removeFirst && remove):
+				ops = append(ops, editReplace)
+				i, j = i+1, j+1
+			case remove:
+				ops = append(ops, editRemove)
+				i++
+			default:
+				ops = append(ops, editInsert)
+				j++
+			}
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, editKeep)
+	}
+	return ops
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
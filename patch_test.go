@@ -0,0 +1,160 @@
+package hjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	//RFC 6902附录A中的例子
+	cases := []struct {
+		doc, patch, expect string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz": "qux", "foo": "bar"}`},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`},
+		{`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`},
+		{`{"foo": {"bar": 1}}`, `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			`{"foo": {"bar": 1}, "baz": {"bar": 2}}`},
+		{`{"foo": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+	}
+	for _, tc := range cases {
+		doc, _ := ToValue([]byte(tc.doc))
+		expect, _ := ToValue([]byte(tc.expect))
+		patch, err := ParsePatch([]byte(tc.patch))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.patch, err)
+		}
+		got, err := patch.Apply(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.patch, err)
+		}
+		if !Equal(expect, got) {
+			t.Errorf("%s: expect:%v got:%v", tc.patch, expect, got)
+		}
+		if original, _ := ToValue([]byte(tc.doc)); !Equal(original, doc) {
+			t.Errorf("%s: document was modified", tc.patch)
+		}
+		if again, err := DecodePatch(patch.ToValue()); err != nil || !Equal(again.ToValue(), patch.ToValue()) {
+			t.Errorf("%s: round trip failed: %v", tc.patch, err)
+		}
+	}
+
+	failures := []struct {
+		doc, patch string
+		index      int
+	}{
+		{`{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, 0},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, 0},
+		{`{"foo": "bar"}`, `[{"op": "remove", "path": "/foo"}, {"op": "replace", "path": "/foo", "value": 1}]`, 1},
+		{`{"foo": {"a": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/b"}]`, 0},
+		{`{"foo": [1]}`, `[{"op": "add", "path": "/foo/2", "value": 1}]`, 0},
+		{`{"foo": [1]}`, `[{"op": "copy", "from": "/bar", "path": "/foo/0"}]`, 0},
+	}
+	for _, tc := range failures {
+		doc, _ := ToValue([]byte(tc.doc))
+		patch, err := ParsePatch([]byte(tc.patch))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.patch, err)
+		}
+		var patchErr *PatchError
+		if _, err := patch.Apply(doc); !errors.As(err, &patchErr) || patchErr.Index != tc.index {
+			t.Errorf("%s: expect PatchError at %d, got:%v", tc.patch, tc.index, err)
+		}
+		if original, _ := ToValue([]byte(tc.doc)); !Equal(original, doc) {
+			t.Errorf("%s: failed patch modified the document", tc.patch)
+		}
+	}
+
+	for _, invalid := range []string{`{}`, `[1]`, `[{"path": "/a"}]`, `[{"op": "add", "path": "/a"}]`,
+		`[{"op": "move", "path": "/a"}]`, `[{"op": "frob", "path": "/a"}]`} {
+		if _, err := ParsePatch([]byte(invalid)); err == nil {
+			t.Errorf("%s: expect error", invalid)
+		}
+	}
+	if _, err := DecodePatch(nil); err == nil || err.Error() != "patch must be an array, got nil" {
+		t.Errorf("expect error for nil patch, got:%v", err)
+	}
+	if _, err := DecodePatch(&JArray{elements: []Value{nil}}); err == nil {
+		t.Errorf("expect error for nil operation")
+	}
+}
+
+func TestCreatePatch(t *testing.T) {
+	cases := []struct {
+		from, to string
+		ops      int
+	}{
+		{`{"a": 1, "b": {"c": [1, 2, 3]}}`, `{"a": 1, "b": {"c": [1, 2, 3]}}`, 0},
+		{`{"a": 1, "b": 2}`, `{"a": 1.0, "c": 2}`, 2},
+		{`[1, 2, 3, 4]`, `[1, 3, 4, 5]`, 2},
+		{`[1, 2, 3]`, `[0, 1, 2, 3]`, 1},
+		{`[{"id": 1, "v": "a"}, {"id": 2, "v": "b"}]`, `[{"id": 1, "v": "a"}, {"id": 2, "v": "c"}]`, 1},
+		{`{"a": [1]}`, `{"a": {"b": 1}}`, 1},
+		{`[]`, `[1, 2]`, 2},
+		{`[1, 2, 3]`, `[]`, 3},
+		{`"x"`, `{"y": [null]}`, 1},
+	}
+	for _, tc := range cases {
+		from, _ := ToValue([]byte(tc.from))
+		to, _ := ToValue([]byte(tc.to))
+		patch := CreatePatch(from, to)
+		if len(patch) != tc.ops {
+			t.Errorf("%s -> %s: expect %d operations, got:%v", tc.from, tc.to, tc.ops, patch.ToValue())
+		}
+		got, err := patch.Apply(from)
+		if err != nil {
+			t.Fatalf("%s -> %s: %v", tc.from, tc.to, err)
+		}
+		if !EqualWithOptions(to, got, EqualOptions{NumericEqual: true}) {
+			t.Errorf("%s -> %s: got:%v with patch %v", tc.from, tc.to, got, patch.ToValue())
+		}
+	}
+}
+
+func TestCreatePatchLargeArrays(t *testing.T) {
+	numbers := func(n, start int) *JArray {
+		array := NewArray()
+		for i := 0; i < n; i++ {
+			array.Append(JNumber(start + i))
+		}
+		return array
+	}
+	cases := []struct {
+		from, to *JArray
+		ops      int
+	}{
+		//相同的首尾不进入编辑距离表
+		{numbers(20000, 0), numbers(20000, 0), 0},
+		{numbers(5000, 0), numbers(5001, 0), 1},
+		//中间的部分超过maxEditCells, 按位置替换
+		{numbers(2000, 0), numbers(3000, 10000), 3000},
+	}
+	for _, tc := range cases {
+		tc.from.Insert(0, JString("head"))
+		tc.to.Insert(0, JString("head"))
+		patch := CreatePatch(tc.from, tc.to)
+		if len(patch) != tc.ops {
+			t.Errorf("%d -> %d elements: expect %d operations, got %d", tc.from.Len(), tc.to.Len(), tc.ops, len(patch))
+		}
+		got, err := patch.Apply(tc.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(tc.to, got) {
+			t.Errorf("%d -> %d elements: patch does not produce the target", tc.from.Len(), tc.to.Len())
+		}
+	}
+}
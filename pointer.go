@@ -0,0 +1,218 @@
+package hjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//PointerError JSON Pointer无法解析或者无法应用到文档上
+type PointerError struct {
+	Pointer string
+	Msg     string
+}
+
+func (e *PointerError) Error() string {
+	return fmt.Sprintf("json pointer %q: %s", e.Pointer, e.Msg)
+}
+
+//ParsePointer 把RFC 6901的JSON Pointer拆分为还原了~0和~1的引用标记, ""表示根节点
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &PointerError{Pointer: pointer, Msg: "must be empty or start with '/'"}
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || token[j+1] != '0' && token[j+1] != '1') {
+				return nil, &PointerError{Pointer: pointer, Msg: "invalid escape sequence"}
+			}
+		}
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+//FormatPointer 把引用标记转义之后拼接为JSON Pointer
+func FormatPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1))
+	}
+	return b.String()
+}
+
+//Pointer 返回p对应的JSON Pointer
+func (p Path) Pointer() string {
+	tokens := make([]string, len(p))
+	for i, seg := range p {
+		switch seg := seg.(type) {
+		case int:
+			tokens[i] = strconv.Itoa(seg)
+		case string:
+			tokens[i] = seg
+		}
+	}
+	return FormatPointer(tokens)
+}
+
+//arrayIndex 解析数组下标, 不允许前导0和符号. allowEnd为true时"-"和n表示末尾之后
+func arrayIndex(token string, n int, allowEnd bool) (int, bool) {
+	if token == "-" {
+		return n, allowEnd
+	}
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return 0, false
+	}
+	for _, r := range token {
+		if !isDigit(r) {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n || i == n && !allowEnd {
+		return 0, false
+	}
+	return i, true
+}
+
+//resolve 按tokens查找值
+func resolve(v Value, pointer string, tokens []string) (Value, error) {
+	if v == nil {
+		return nil, &PointerError{Pointer: pointer, Msg: "document is nil"}
+	}
+	for _, token := range tokens {
+		if obj, ok := AsObject(v); ok {
+			next, ok := obj.values[token]
+			if !ok {
+				return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("key %q not found", token)}
+			}
+			v = next
+		} else if array, ok := AsArray(v); ok {
+			i, ok := arrayIndex(token, len(array.elements), false)
+			if !ok {
+				return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("invalid array index %q", token)}
+			}
+			v = array.elements[i]
+		} else {
+			return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("cannot find %q in %s", token, typeName(v))}
+		}
+	}
+	return v, nil
+}
+
+//Get 返回v中pointer指向的值, 如Get(v, "/servers/0/host")
+func Get(v Value, pointer string) (Value, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(v, pointer, tokens)
+}
+
+//Set 把pointer处的值替换为nv, 对象中不存在的键会被添加, 数组中"-"表示追加.
+//容器在原地修改, 返回新的根节点(pointer为""时就是nv)
+func Set(v Value, pointer string, nv Value) (Value, error) {
+	return modify(v, pointer, nv, false)
+}
+
+//Add 按RFC 6902的add操作修改v: 对象中添加或替换键, 数组中在下标处插入, "-"表示追加.
+//容器在原地修改, 返回新的根节点
+func Add(v Value, pointer string, nv Value) (Value, error) {
+	return modify(v, pointer, nv, true)
+}
+
+func modify(v Value, pointer string, nv Value, insert bool) (Value, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if nv == nil {
+		nv = JNull{}
+	}
+	if len(tokens) == 0 {
+		return nv, nil
+	}
+	parent, err := resolve(v, pointer, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	if obj, ok := AsObject(parent); ok {
+		obj.Set(last, nv)
+		//没有map的JObject值经AsObject得到的是副本, Set在副本上创建了map, 需要把副本写回
+		if o, isValue := parent.(JObject); isValue && o.values == nil {
+			return modify(v, FormatPointer(tokens[:len(tokens)-1]), *obj, false)
+		}
+		return v, nil
+	}
+	if array, ok := AsArray(parent); ok {
+		//Set只有"-"表示追加, 等于长度的下标越界
+		i, ok := arrayIndex(last, len(array.elements), insert || last == "-")
+		if !ok {
+			return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("invalid array index %q", last)}
+		}
+		if insert || i == len(array.elements) {
+			if _, isValue := parent.(JArray); isValue {
+				return nil, errArrayValue(pointer)
+			}
+			array.Insert(i, nv)
+		} else {
+			array.elements[i] = nv
+		}
+		return v, nil
+	}
+	return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("cannot set %q in %s", last, typeName(parent))}
+}
+
+//Remove 删除pointer处的值并返回它, 不能删除根节点
+func Remove(v Value, pointer string) (Value, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &PointerError{Pointer: pointer, Msg: "cannot remove the root"}
+	}
+	parent, err := resolve(v, pointer, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	if obj, ok := AsObject(parent); ok {
+		old, ok := obj.values[last]
+		if !ok {
+			return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("key %q not found", last)}
+		}
+		obj.Delete(last)
+		return old, nil
+	}
+	if array, ok := AsArray(parent); ok {
+		i, ok := arrayIndex(last, len(array.elements), false)
+		if !ok {
+			return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("invalid array index %q", last)}
+		}
+		if _, isValue := parent.(JArray); isValue {
+			return nil, errArrayValue(pointer)
+		}
+		return array.RemoveAt(i)
+	}
+	return nil, &PointerError{Pointer: pointer, Msg: fmt.Sprintf("cannot remove %q from %s", last, typeName(parent))}
+}
+
+//errArrayValue JArray值经AsArray得到的是副本, 改变长度的修改不会反映到文档中, 因此报错
+func errArrayValue(pointer string) error {
+	return &PointerError{Pointer: pointer, Msg: "cannot change the length of a JArray value in place, use *JArray"}
+}
+
+//typeName 返回v的类型名, nil为"nil"
+func typeName(v Value) string {
+	if v == nil {
+		return "nil"
+	}
+	return v.Type().String()
+}
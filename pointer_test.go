@@ -0,0 +1,130 @@
+package hjson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	//RFC 6901第5节的例子
+	value, err := ToValue([]byte(`{
+		"foo": ["bar", "baz"], "": 0, "a/b": 1, "c%d": 2, "e^f": 3,
+		"g|h": 4, "i\\j": 5, "k\"l": 6, " ": 7, "m~n": 8
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]Value{
+		"":       value,
+		"/foo":   &JArray{elements: []Value{JString("bar"), JString("baz")}},
+		"/foo/0": JString("bar"),
+		"/":      JNumber(0),
+		"/a~1b":  JNumber(1),
+		"/c%d":   JNumber(2),
+		"/e^f":   JNumber(3),
+		"/g|h":   JNumber(4),
+		"/i\\j":  JNumber(5),
+		"/k\"l":  JNumber(6),
+		"/ ":     JNumber(7),
+		"/m~0n":  JNumber(8),
+	}
+	for pointer, expect := range cases {
+		got, err := Get(value, pointer)
+		if err != nil {
+			t.Fatalf("%q: %v", pointer, err)
+		}
+		if !Equal(expect, got) {
+			t.Errorf("%q: expect:%v got:%v", pointer, expect, got)
+		}
+	}
+	for _, pointer := range []string{"foo", "/foo/2", "/foo/01", "/foo/-", "/foo/-1", "/x", "/foo/0/x", "/m~2n"} {
+		var pointerErr *PointerError
+		if _, err := Get(value, pointer); !errors.As(err, &pointerErr) {
+			t.Errorf("%q: expect PointerError, got:%v", pointer, err)
+		}
+	}
+	if tokens, _ := ParsePointer("/m~0n/a~1b/~01"); FormatPointer(tokens) != "/m~0n/a~1b/~01" || tokens[2] != "~1" {
+		t.Fatalf("unexpected tokens:%q", tokens)
+	}
+	if pointer := (Path{"a/b", 0, "m~n"}).Pointer(); pointer != "/a~1b/0/m~0n" {
+		t.Fatalf("unexpected pointer:%s", pointer)
+	}
+}
+
+func TestPointerModify(t *testing.T) {
+	value, err := ToValue([]byte(`{"servers": [{"host": "a"}], "port": 80}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		op      func(Value) (Value, error)
+		pointer string
+		expect  Value
+	}{
+		{func(v Value) (Value, error) { return Set(v, "/servers/0/host", JString("b")) }, "/servers/0/host", JString("b")},
+		{func(v Value) (Value, error) { return Set(v, "/servers/-", JString("c")) }, "/servers/1", JString("c")},
+		{func(v Value) (Value, error) { return Add(v, "/servers/0", JString("d")) }, "/servers/0", JString("d")},
+		{func(v Value) (Value, error) { return Add(v, "/tls", nil) }, "/tls", JNull{}},
+		{func(v Value) (Value, error) { return Set(v, "/port", JNumber(8080)) }, "/port", JNumber(8080)},
+	}
+	for _, step := range steps {
+		value, err = step.op(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := Get(value, step.pointer); err != nil || !Equal(step.expect, got) {
+			t.Fatalf("%s: expect:%v got:%v %v", step.pointer, step.expect, got, err)
+		}
+	}
+	if servers, _ := Get(value, "/servers"); servers.(*JArray).Len() != 3 {
+		t.Fatalf("expect 3 servers, got:%v", servers)
+	}
+	if old, err := Remove(value, "/servers/0"); err != nil || old != JString("d") {
+		t.Fatalf("expect removed d, got:%v %v", old, err)
+	}
+	if old, err := Remove(value, "/tls"); err != nil || !IsNull(old) {
+		t.Fatalf("expect removed null, got:%v %v", old, err)
+	}
+	if root, err := Set(value, "", JNumber(1)); err != nil || root != JNumber(1) {
+		t.Fatalf("expect root to be replaced, got:%v %v", root, err)
+	}
+
+	for _, err := range []error{
+		func() error { _, err := Set(value, "/servers/5", JNull{}); return err }(),
+		func() error { _, err := Set(value, "/servers/2", JNull{}); return err }(),
+		func() error { _, err := Add(value, "/missing/x", JNull{}); return err }(),
+		func() error { _, err := Add(value, "/port/x", JNull{}); return err }(),
+		func() error { _, err := Remove(value, ""); return err }(),
+		func() error { _, err := Remove(value, "/servers/-"); return err }(),
+		func() error { _, err := Remove(value, "/nope"); return err }(),
+		func() error { _, err := Add(JArray{}, "/-", JNull{}); return err }(),
+		func() error { _, err := Get(nil, "/a"); return err }(),
+		func() error { _, err := Set(nil, "/a", JNull{}); return err }(),
+		func() error { _, err := Remove(nil, "/a"); return err }(),
+		func() error { _, err := Get(&JArray{elements: []Value{nil}}, "/0/a"); return err }(),
+		func() error { _, err := Set(&JArray{elements: []Value{nil}}, "/0/a", JNull{}); return err }(),
+		func() error { _, err := Remove(JArray{elements: []Value{JNull{}}}, "/0"); return err }(),
+	} {
+		if _, ok := err.(*PointerError); !ok {
+			t.Errorf("expect PointerError, got:%v", err)
+		}
+	}
+	if servers, _ := Get(value, "/servers"); servers.(*JArray).Len() != 2 {
+		t.Fatalf("expect Set past the end not to append, got:%v", servers)
+	}
+
+	//没有map的JObject值
+	for _, doc := range []Value{JObject{}, &JArray{elements: []Value{JObject{}}}} {
+		pointer := "/a"
+		if _, ok := doc.(*JArray); ok {
+			pointer = "/0/a"
+		}
+		root, err := Set(doc, pointer, JNumber(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := Get(root, pointer); err != nil || got != JNumber(1) {
+			t.Errorf("%s: expect 1, got:%v %v", pointer, got, err)
+		}
+	}
+}
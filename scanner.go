@@ -3,10 +3,15 @@ package hjson
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 const (
@@ -46,14 +51,67 @@ var (
 	errEOF = errors.New("unexpected of JSON input")
 )
 
+//记号的上下文, Hjson和JSON5中键和值的词法不同
+const (
+	modeValue = iota
+	modeKey
+)
+
+//char 读取过的字符, 用于回退
+type char struct {
+	r    rune
+	size int
+	//r为U+FFFD且size为1时是输入中的非法字节
+	b byte
+}
+
 type scanner struct {
 	reader *bufio.Reader
 	buf    *bytes.Buffer
 	line   int
 	pos    int
+	offset int
 	err    error
+	//上一个字符的位置, 用于unread
+	prevLine int
+	prevPos  int
+	last     char
+	//回退的字符, 优先于reader读取
+	pending []char
+	//mark之后读取的字符和mark时的位置, 用于reset
+	marking bool
+	marked  []char
+	markPos [3]int
+	//当前记号的起始位置
+	tokLine   int
+	tokPos    int
+	tokOffset int
+	//当前记号的结束位置, trimmed是无引号的值末尾去掉的空白的字节数
+	tokEnd  Position
+	trimmed int
+	//当前记号之前是否出现过换行
+	newline bool
+	dialect Dialect
+	//由parser设置, 表示下一个记号是键还是值
+	mode int
+	//字符串记号转义之后的值
+	value  string
+	limits Limits
+	//当前记号字面值的长度限制及其名字
+	litMax  int
+	litName string
+	//读取失败(超出限制、reader出错或ctx被取消)的错误, 之后的记号都是tokenInvalid
+	fatal error
+	//ctx不为nil时每读取ctxCheckInterval个字符检查一次是否被取消
+	ctx   context.Context
+	count int
+	//InvalidUTF8Error时当前记号中第一个非法字节的错误
+	invalidUTF8 InvalidUTF8
+	encErr      *SyntaxError
 }
 
+const ctxCheckInterval = 4096
+
 func newScanner(reader io.Reader) *scanner {
 	return &scanner{
 		reader: bufio.NewReader(reader),
@@ -63,25 +121,182 @@ func newScanner(reader io.Reader) *scanner {
 	}
 }
 
+//read 读取一个字符并更新位置信息
+func (s *scanner) read() (rune, error) {
+	if s.ctx != nil && s.count%ctxCheckInterval == 0 && s.fatal == nil {
+		if err := s.ctx.Err(); err != nil {
+			s.fatal = err
+		}
+	}
+	s.count++
+	var c char
+	if len(s.pending) > 0 {
+		c = s.pending[0]
+		s.pending = s.pending[1:]
+	} else {
+		r, size, err := s.reader.ReadRune()
+		if err != nil {
+			if err != io.EOF && s.fatal == nil {
+				s.fatal = err
+			}
+			return r, err
+		}
+		c = char{r: r, size: size}
+		if r == utf8.RuneError && size == 1 {
+			s.reader.UnreadRune()
+			c.b, _ = s.reader.ReadByte()
+		}
+	}
+	if err := s.checkLimits(c.size); err != nil {
+		s.fatal = err
+		return 0, err
+	}
+	if s.marking {
+		s.marked = append(s.marked, c)
+	}
+	s.checkUTF8(c)
+	s.prevLine, s.prevPos, s.last = s.line, s.pos, c
+	s.offset += c.size
+	if c.r == '\n' {
+		s.line++
+		s.pos = 1
+	} else {
+		s.pos++
+	}
+	return c.r, nil
+}
+
+//checkLimits 检查输入的总长度和当前记号字面值的长度
+func (s *scanner) checkLimits(size int) error {
+	if s.fatal != nil {
+		return s.fatal
+	}
+	if max := s.limits.MaxBytes; max > 0 && s.offset+size > max {
+		return s.limitError("MaxBytes", max)
+	}
+	if s.litMax > 0 && s.buf.Len() > s.litMax {
+		return s.limitError(s.litName, s.litMax)
+	}
+	return nil
+}
+
+//limitLiteral 设置之后读取的字面值的长度限制
+func (s *scanner) limitLiteral(name string, max int) {
+	s.litName, s.litMax = name, max
+}
+
+func (s *scanner) limitError(name string, max int) *LimitError {
+	return &LimitError{
+		Limit:    name,
+		Max:      max,
+		Position: Position{s.line, s.pos, s.offset},
+	}
+}
+
+//unread 回退最近读取的一个字符
+func (s *scanner) unread() {
+	s.pending = append([]char{s.last}, s.pending...)
+	if s.marking {
+		s.marked = s.marked[:len(s.marked)-1]
+	}
+	s.line, s.pos = s.prevLine, s.prevPos
+	s.offset -= s.last.size
+}
+
+//mark 记录当前位置, 之后可以用reset回到这里
+func (s *scanner) mark() {
+	s.marking = true
+	s.marked = s.marked[:0]
+	s.markPos = [3]int{s.line, s.pos, s.offset}
+}
+
+//reset 回退mark之后读取的所有字符
+func (s *scanner) reset() {
+	s.pending = append(append([]char{}, s.marked...), s.pending...)
+	s.marking = false
+	s.line, s.pos, s.offset = s.markPos[0], s.markPos[1], s.markPos[2]
+}
+
+//pushBack 回退同一行内刚读取的字符串str
+func (s *scanner) pushBack(str string) {
+	chars := make([]char, 0, len(str))
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		chars = append(chars, char{r, size, str[i]})
+		i += size
+	}
+	s.pending = append(chars, s.pending...)
+	s.pos -= len(chars)
+	s.offset -= len(str)
+}
+
+//errorf 生成位于当前位置的语法错误
+func (s *scanner) errorf(format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: Position{s.line, s.pos, s.offset},
+	}
+}
+
 func (s *scanner) nextToken() (int, string) {
+	s.newline = false
+	s.err = nil
+	s.value = ""
+	s.encErr = nil
+	s.trimmed = 0
+	s.limitLiteral("", 0)
+	tok, lit := s.scanToken()
+	s.tokEnd = Position{s.line, s.pos - s.trimmed, s.offset - s.trimmed}
+	if s.fatal != nil {
+		s.err = s.fatal
+		return tokenInvalid, lit
+	}
+	if s.encErr != nil {
+		s.err = s.encErr
+		return tokenInvalid, lit
+	}
+	return tok, lit
+}
+
+func (s *scanner) scanToken() (int, string) {
 	for {
-		r, _, err := s.reader.ReadRune()
+		s.tokLine, s.tokPos, s.tokOffset = s.line, s.pos, s.offset
+		r, err := s.read()
 		if err != nil {
 			break
 		}
-		if isWhitespace(r) {
+		if s.isWhitespace(r) {
+			continue
+		}
+		if s.skipComment(r) {
+			if s.err != nil {
+				return tokenInvalid, ""
+			}
 			continue
 		}
 		switch r {
+		case '\'':
+			if s.dialect == DialectJSON {
+				break
+			}
+			if s.dialect == DialectHjson && s.mode == modeValue {
+				if tok, lit, ok := s.scanMultiline(); ok {
+					return tok, lit
+				}
+			}
+			fallthrough
 		case '"':
 			s.buf.Reset()
-			if err := s.scanString(); err != nil {
+			s.limitLiteral("MaxStringLength", s.limits.MaxStringLength)
+			if err := s.scanString(r); err != nil {
 				if err == errEOF {
+					s.err = s.errorf("unterminated string")
 					goto out
 				}
 				s.err = err
 				return tokenInvalid, s.buf.String()
 			}
+			s.value = unescape(s.buf.String())
 			return tokenString, s.buf.String()
 		case ',':
 			return tokenComma, ","
@@ -96,33 +311,46 @@ func (s *scanner) nextToken() (int, string) {
 		case ']':
 			return tokenRBracket, "]"
 		case '\n':
-			s.line++
+			s.newline = true
+			continue
 		default:
 			s.buf.Reset()
-			s.reader.UnreadRune()
-			if unicode.IsDigit(r) {
+			s.unread()
+			s.limitLiteral("MaxStringLength", s.limits.MaxStringLength)
+			if s.dialect == DialectHjson {
+				if s.mode == modeKey {
+					return s.scanHjsonKey()
+				}
+				return s.scanQuoteless()
+			}
+			if s.dialect == DialectJSON5 && s.mode == modeKey && isIdentStart(r) {
+				s.scanIdentifier()
+				s.value = s.buf.String()
+				return tokenString, s.value
+			}
+			if isDigit(r) || r == '-' || s.dialect == DialectJSON5 && (r == '+' || r == '.') {
+				s.limitLiteral("MaxNumberLength", s.limits.MaxNumberLength)
 				if err := s.scanNumber(); err != nil {
-					if err == errEOF {
-						goto out
-					}
 					s.err = err
 					return tokenInvalid, s.buf.String()
 				}
 				return tokenNumber, s.buf.String()
-			} else {
-				if err := s.scanIdent(); err != nil {
-					goto out
-				}
+			} else if unicode.IsLetter(r) {
+				s.scanIdent()
 				lit := s.buf.String()
-				return lookup(lit), lit
+				return s.lookup(lit), lit
 			}
+			s.read()
 		}
+		//无法识别的字符, 已经跳过它以免重复读取
+		s.err = s.errorf("invalid character: %q", r)
+		return tokenInvalid, string(r)
 	}
 out:
 	return tokenEOF, ""
 }
 
-func lookup(lit string) int {
+func (s *scanner) lookup(lit string) int {
 	switch lit {
 	case "null":
 		return tokenNull
@@ -130,142 +358,340 @@ func lookup(lit string) int {
 		return tokenTrue
 	case "false":
 		return tokenFalse
+	case "Infinity", "NaN":
+		if s.dialect == DialectJSON5 {
+			return tokenNumber
+		}
 	}
 	return tokenInvalid
 }
 
-func (s *scanner) scanHex() error {
-	for i := 0; i < 4; i++ {
-		r, _, err := s.reader.ReadRune()
+//scanHex 读取n个十六进制数字
+func (s *scanner) scanHex(n int) error {
+	for i := 0; i < n; i++ {
+		r, err := s.read()
 		if err != nil {
 			return errEOF
 		}
-		if (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F') {
+		if isHexDigit(r) {
 			s.buf.WriteRune(r)
 			continue
 		}
-		return fmt.Errorf("encounter invalid hexadecimal digit:%s", string(r))
+		//非法字符可能是结束的引号, 留给调用者处理
+		s.unread()
+		return s.errorf("encounter invalid hexadecimal digit:%s", string(r))
 	}
 	return nil
 }
-func (s *scanner) scanString() error {
+
+//scanString 扫描以quote结尾的字符串, 字面值保留转义序列.
+//遇到非法的转义序列时记录第一个错误并继续读到字符串结尾,
+//这样错误恢复模式下后续的记号不会错位
+func (s *scanner) scanString(quote rune) error {
+	var first error
 	for {
-		r, _, err := s.reader.ReadRune()
+		r, err := s.read()
 		if err != nil {
-			//return errEOF
+			return errEOF
+		}
+		if r == quote {
 			break
 		}
-		if r == '\\' {
-			s.buf.WriteRune(r)
-			r, _, err = s.reader.ReadRune()
-			if err != nil {
-				return errEOF
+		if s.isControl(r) && first == nil {
+			first = &SyntaxError{
+				Msg:      fmt.Sprintf("invalid control character %q in string", r),
+				Position: Position{s.prevLine, s.prevPos, s.offset - s.last.size},
 			}
-			switch r {
-			case '"':
-			case '\\':
-			case '/':
-			case 'b':
-			case 'f':
-			case 'n':
-			case 'r':
-			case 't':
-			case 'u':
-				s.buf.WriteRune(r)
-				if err := s.scanHex(); err != nil {
-					return err
-				}
-				goto next
-			default:
-				return fmt.Errorf("invalid escape sequence: %s", string(r))
+		}
+		s.write(r)
+		if r != '\\' {
+			continue
+		}
+		r, err = s.read()
+		if err != nil {
+			return errEOF
+		}
+		if err := s.scanEscape(r); err != nil {
+			if err == errEOF {
+				return err
+			}
+			if first == nil {
+				first = err
 			}
-		} else if r == '"' {
+		}
+	}
+	return first
+}
+
+//scanEscape 检查'\\'之后的转义字符r是否在当前语法中合法
+func (s *scanner) scanEscape(r rune) error {
+	s.buf.WriteRune(r)
+	switch r {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return nil
+	case 'u':
+		return s.scanHex(4)
+	case '\'':
+		if s.dialect != DialectJSON {
+			return nil
+		}
+	default:
+		if s.dialect != DialectJSON5 {
 			break
 		}
+		switch {
+		case r == 'x':
+			return s.scanHex(2)
+		case r == '0':
+			//\0之后不能紧跟数字
+			if next, err := s.read(); err == nil {
+				s.unread()
+				if isDigit(next) {
+					break
+				}
+			}
+			return nil
+		case isDigit(r):
+		default:
+			//JSON5中其他字符(包括换行)转义后是它本身
+			return nil
+		}
+	}
+	return s.errorf("invalid escape sequence: %s", string(r))
+}
+
+//scanNumber 按JSON的语法扫描数字: -?(0|[1-9][0-9]*)(.[0-9]+)?([eE][+-]?[0-9]+)?
+//JSON5还允许十六进制、前导的'+'、省略整数或小数部分以及Infinity和NaN
+func (s *scanner) scanNumber() error {
+	json5 := s.dialect == DialectJSON5
+	r, _ := s.read()
+	if r == '-' || json5 && r == '+' {
+		s.buf.WriteRune(r)
+		r, _ = s.read()
+	}
+	intDigits := 0
+	switch {
+	case json5 && (r == 'I' || r == 'N'):
+		s.unread()
+		sign := s.buf.String()
+		s.scanIdent()
+		if lit := s.buf.String()[len(sign):]; lit != "Infinity" && lit != "NaN" {
+			return s.errorf("invalid numeric literal: %s", s.buf.String())
+		}
+		return nil
+	case r == '0':
+		s.buf.WriteRune(r)
+		intDigits = 1
+		if json5 {
+			if x, err := s.read(); err == nil && (x == 'x' || x == 'X') {
+				s.buf.WriteRune(x)
+				if s.scanHexDigits() == 0 {
+					r, _ = s.read()
+					return s.badNumber(r)
+				}
+				return s.endNumber()
+			} else if err == nil {
+				s.unread()
+			}
+		}
+	case r >= '1' && r <= '9':
+		s.buf.WriteRune(r)
+		intDigits = 1 + s.scanDigits()
+	case json5 && r == '.':
+		s.unread()
+	default:
+		return s.badNumber(r)
+	}
+	r, err := s.read()
+	if err == nil && r == '.' {
+		s.buf.WriteRune(r)
+		if s.scanDigits() == 0 && (!json5 || intDigits == 0) {
+			r, _ = s.read()
+			return s.badNumber(r)
+		}
+		r, err = s.read()
+	}
+	if err == nil && (r == 'e' || r == 'E') {
 		s.buf.WriteRune(r)
-	next:
+		r, err = s.read()
+		if err == nil && (r == '+' || r == '-') {
+			s.buf.WriteRune(r)
+		} else if err == nil {
+			s.unread()
+		}
+		if s.scanDigits() == 0 {
+			r, _ = s.read()
+			return s.badNumber(r)
+		}
+		r, err = s.read()
+	}
+	if err == nil {
+		s.unread()
+	}
+	return s.endNumber()
+}
+
+//endNumber 数字后面紧跟字母或数字(如 012, 12df)都是非法的
+func (s *scanner) endNumber() error {
+	r, err := s.read()
+	if err != nil {
+		return nil
+	}
+	if isDigit(r) || unicode.IsLetter(r) || r == '.' {
+		return s.badNumber(r)
 	}
+	s.unread()
 	return nil
 }
 
-//.12 or .12e13 .12E13 .12e+13 .12e-13
-func (s *scanner) scanFraction() error {
-	sawE := false
-	sawSign := false
+//scanHexDigits 读取连续的十六进制数字, 返回读取的个数
+func (s *scanner) scanHexDigits() int {
+	n := 0
 	for {
-		r, _, err := s.reader.ReadRune()
+		r, err := s.read()
 		if err != nil {
-			return errEOF
+			return n
 		}
-		switch r {
-		case '-', '+':
-			if !sawSign {
-				s.buf.WriteRune(r)
-				sawSign = true
-			} else {
-				return fmt.Errorf(`invalid character:'%s' in numeric literal`, string(r))
-			}
-		case 'e', 'E':
-			if sawSign && !sawE {
-				return fmt.Errorf(`invalid character:'%s' in numeric literal`, string(r))
-			} else if !sawE {
-				s.buf.WriteRune(r)
-				sawE = true
-			} else {
-				return fmt.Errorf(`invalid character:'%s' in numeric literal`, string(r))
-			}
-		default:
-			if unicode.IsDigit(r) {
-				s.buf.WriteRune(r)
-			} else {
-				s.reader.UnreadRune()
-				return nil
-			}
+		if !isHexDigit(r) {
+			s.unread()
+			return n
 		}
+		s.buf.WriteRune(r)
+		n++
 	}
 }
 
-func (s *scanner) scanNumber() error {
+//scanDigits 读取连续的十进制数字, 返回读取的个数
+func (s *scanner) scanDigits() int {
+	n := 0
 	for {
-		r, _, err := s.reader.ReadRune()
+		r, err := s.read()
 		if err != nil {
-			break
+			return n
 		}
-		if unicode.IsDigit(r) {
-			s.buf.WriteRune(r)
-		} else if r == '.' { //int frac
-			s.buf.WriteRune(r)
-			return s.scanFraction()
-		} else if r == 'e' || r == 'E' { //int exp
-			s.buf.WriteRune(r)
-			return s.scanFraction()
-		} else {
-			if !unicode.IsDigit(r) {
-				s.reader.UnreadRune()
-				break
-			}
+		if !isDigit(r) {
+			s.unread()
+			return n
 		}
+		s.buf.WriteRune(r)
+		n++
 	}
-	return nil
 }
-func (s *scanner) scanIdent() error {
+
+//badNumber 数字中出现非法字符r, r为0表示遇到了EOF.
+//非法字符会被回退, 留给后续的记号
+func (s *scanner) badNumber(r rune) error {
+	if r == 0 {
+		return s.errorf("unexpected end of numeric literal: %s", s.buf.String())
+	}
+	s.unread()
+	return s.errorf(`invalid character:'%s' in numeric literal`, string(r))
+}
+
+func (s *scanner) scanIdent() {
 	for {
-		r, _, err := s.reader.ReadRune()
+		r, err := s.read()
 		if err != nil {
-			return errEOF
+			return
 		}
 		if unicode.IsLetter(r) {
 			s.buf.WriteRune(r)
 		} else {
-			s.reader.UnreadRune()
-			break
+			s.unread()
+			return
 		}
 	}
-	return nil
 }
-func isWhitespace(r rune) bool {
+func (s *scanner) isWhitespace(r rune) bool {
 	if r == ' ' || r == '\t' || r == '\r' {
 		return true
 	}
+	if s.dialect == DialectJSON5 {
+		//ECMAScript的空白和行结束符(换行单独处理)
+		switch r {
+		case '\v', '\f', 0xA0, 0xFEFF, 0x2028, 0x2029:
+			return true
+		}
+		return unicode.Is(unicode.Zs, r)
+	}
 	return false
 }
+
+//unescape 把经过校验的带引号字符串字面值中的转义序列还原
+func unescape(raw string) string {
+	if !strings.ContainsRune(raw, '\\') {
+		return raw
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' || i+1 == len(raw) {
+			b.WriteByte(raw[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(raw[i+1:])
+		i += 1 + size
+		switch r {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case 'x', 'u':
+			n := 2
+			if r == 'u' {
+				n = 4
+			}
+			if i+n > len(raw) {
+				break
+			}
+			v, _ := strconv.ParseUint(raw[i:i+n], 16, 32)
+			i += n
+			c := rune(v)
+			//代理对
+			if utf16.IsSurrogate(c) && i+6 <= len(raw) && raw[i] == '\\' && raw[i+1] == 'u' {
+				if low, err := strconv.ParseUint(raw[i+2:i+6], 16, 32); err == nil {
+					if d := utf16.DecodeRune(c, rune(low)); d != utf8.RuneError {
+						c = d
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(c)
+		case '\r':
+			//JSON5的续行
+			if i < len(raw) && raw[i] == '\n' {
+				i++
+			}
+		case '\n', 0x2028, 0x2029:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+//isControl 字符串中不能直接出现的控制字符. JSON5只禁止行结束符
+func (s *scanner) isControl(r rune) bool {
+	if s.dialect == DialectJSON5 {
+		return r == '\n' || r == '\r'
+	}
+	return r < 0x20
+}
+
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
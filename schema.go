@@ -0,0 +1,833 @@
+package hjson
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//JSON Schema校验, 支持2020-12, 并兼容draft-07及之前的写法: definitions,
+//数组形式的items和additionalItems, dependencies, 以"#"开头的$id.
+//$schema声明为draft-07及之前时$ref忽略同一对象中的其他关键字.
+//$ref只能引用schema文档内部的位置: JSON Pointer片段、$anchor或者子schema的$id.
+//不支持unevaluatedProperties, unevaluatedItems和$dynamicRef
+
+//SchemaError schema本身不合法, Pointer是出错的位置
+type SchemaError struct {
+	Pointer string
+	Msg     string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid schema at %q: %s", e.Pointer, e.Msg)
+}
+
+//ValidationError 实例中的一个值不满足schema中的一个关键字
+type ValidationError struct {
+	//InstancePath 出错的值在实例中的JSON Pointer
+	InstancePath string
+	//SchemaPath 关键字在schema文档中的JSON Pointer, 经过$ref时是引用目标中的位置
+	SchemaPath string
+	Keyword    string
+	Msg        string
+	//Span 出错的值在输入中的范围, 只有校验时提供了SourceMap才有
+	Span *Span
+}
+
+func (e *ValidationError) Error() string {
+	loc := fmt.Sprintf("%q", e.InstancePath)
+	if e.Span != nil {
+		loc = fmt.Sprintf("%s %s", e.Span.Start, loc)
+	}
+	return fmt.Sprintf("%s: %s (%s)", loc, e.Msg, e.SchemaPath)
+}
+
+//ValidationErrors 校验发现的所有错误, 按校验的顺序排列
+type ValidationErrors []*ValidationError
+
+func (l ValidationErrors) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%d errors:\n%s", len(l), strings.Join(msgs, "\n"))
+}
+
+//Err 没有错误时返回nil
+func (l ValidationErrors) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+//Schema 编译好的JSON Schema, 可以并发使用
+type Schema struct {
+	root Value
+	//legacy draft-07及之前的版本
+	legacy bool
+	//patterns 以pattern关键字或patternProperties中的键的位置为索引
+	patterns map[string]*regexp.Regexp
+	//refs 以含有$ref的schema的位置为索引
+	refs map[string]schemaRef
+}
+
+type schemaRef struct {
+	path   []string
+	schema Value
+}
+
+//ValidateOptions ValidateWithOptions的选项
+type ValidateOptions struct {
+	//SourceMap 解析实例时记录的范围, 用来填写ValidationError.Span
+	SourceMap *SourceMap
+}
+
+//ParseSchema 解析JSON格式的schema并编译
+func ParseSchema(data []byte) (*Schema, error) {
+	v, err := ToValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return CompileSchema(v)
+}
+
+//CompileSchema 检查schema, 编译其中的正则表达式并解析所有的$ref
+func CompileSchema(schema Value) (*Schema, error) {
+	s := &Schema{
+		root:     schema,
+		patterns: make(map[string]*regexp.Regexp),
+		refs:     make(map[string]schemaRef),
+	}
+	if obj, ok := AsObject(schema); ok {
+		if version, ok := AsString(obj.values["$schema"]); ok {
+			s.legacy = strings.Contains(version, "draft-0")
+		}
+	}
+	c := &schemaCompiler{
+		schema:    s,
+		resources: make(map[string][]string),
+		anchors:   make(map[string][]string),
+		refs:      make(map[string]string),
+	}
+	base, _ := url.Parse("urn:hjson:schema")
+	if err := c.walk(schema, nil, base); err != nil {
+		return nil, err
+	}
+	if err := c.resolve(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type schemaCompiler struct {
+	schema *Schema
+	//resources 子schema的$id(不含片段)对应的位置
+	resources map[string][]string
+	//anchors "$id#anchor"对应的位置
+	anchors map[string][]string
+	//refs 含有$ref的schema的位置对应的绝对引用
+	refs map[string]string
+}
+
+//schemaKeywords 值为子schema的关键字, 以及值是schema的数组或对象的关键字
+var (
+	schemaKeywords = []string{"additionalProperties", "additionalItems", "contains", "propertyNames",
+		"not", "if", "then", "else"}
+	schemaArrayKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords   = []string{"properties", "patternProperties", "$defs", "definitions",
+		"dependentSchemas", "dependencies"}
+	schemaTypes = map[string]bool{"null": true, "boolean": true, "object": true, "array": true,
+		"number": true, "integer": true, "string": true}
+)
+
+func (c *schemaCompiler) errorf(path []string, format string, args ...interface{}) *SchemaError {
+	return &SchemaError{Pointer: FormatPointer(path), Msg: fmt.Sprintf(format, args...)}
+}
+
+//walk 检查path处的schema并记录其中的$id, $anchor和$ref, base是当前的基准URI
+func (c *schemaCompiler) walk(schema Value, path []string, base *url.URL) error {
+	if _, ok := schema.(JBool); ok {
+		return nil
+	}
+	obj, ok := AsObject(schema)
+	if !ok {
+		return c.errorf(path, "schema must be an object or a boolean, got %s", schema.Type())
+	}
+	if len(path) == 0 {
+		c.resources[resource(base)] = path
+	}
+	if id, ok := AsString(obj.values["$id"]); ok {
+		u, err := base.Parse(id)
+		if err != nil {
+			return c.errorf(childPath(path, "$id"), "%v", err)
+		}
+		if strings.HasPrefix(id, "#") {
+			c.anchors[u.String()] = path
+		} else {
+			base = u
+			c.resources[resource(u)] = path
+		}
+	}
+	if anchor, ok := AsString(obj.values["$anchor"]); ok {
+		c.anchors[resource(base)+"#"+anchor] = path
+	}
+	if ref, ok := obj.values["$ref"]; ok {
+		str, ok := AsString(ref)
+		if !ok {
+			return c.errorf(childPath(path, "$ref"), "$ref must be a string")
+		}
+		u, err := base.Parse(str)
+		if err != nil {
+			return c.errorf(childPath(path, "$ref"), "%v", err)
+		}
+		c.refs[FormatPointer(path)] = u.String()
+	}
+	if err := c.check(obj, path); err != nil {
+		return err
+	}
+	for _, keyword := range schemaKeywords {
+		if sub, ok := obj.values[keyword]; ok {
+			if err := c.walk(sub, childPath(path, keyword), base); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range append(schemaArrayKeywords, "items") {
+		value, ok := obj.values[keyword]
+		if !ok {
+			continue
+		}
+		array, ok := AsArray(value)
+		if !ok {
+			if keyword == "items" {
+				if err := c.walk(value, childPath(path, keyword), base); err != nil {
+					return err
+				}
+				continue
+			}
+			return c.errorf(childPath(path, keyword), "%s must be an array", keyword)
+		}
+		for i, sub := range array.elements {
+			if err := c.walk(sub, indexPath(childPath(path, keyword), i), base); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaMapKeywords {
+		value, ok := obj.values[keyword]
+		if !ok {
+			continue
+		}
+		m, ok := AsObject(value)
+		if !ok {
+			return c.errorf(childPath(path, keyword), "%s must be an object", keyword)
+		}
+		for _, key := range m.Keys() {
+			sub := m.values[key]
+			if _, ok := AsArray(sub); ok && keyword == "dependencies" {
+				continue
+			}
+			if keyword == "patternProperties" {
+				if err := c.compilePattern(childPath(path, keyword, key), key); err != nil {
+					return err
+				}
+			}
+			if err := c.walk(sub, childPath(path, keyword, key), base); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//check 检查不含子schema的关键字的类型
+func (c *schemaCompiler) check(obj *JObject, path []string) error {
+	if t, ok := obj.values["type"]; ok {
+		names := []Value{t}
+		if array, ok := AsArray(t); ok {
+			names = array.elements
+		}
+		for _, name := range names {
+			if s, _ := AsString(name); !schemaTypes[s] {
+				return c.errorf(childPath(path, "type"), "invalid type %s", name)
+			}
+		}
+	}
+	for _, keyword := range []string{"multipleOf", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+		"maxLength", "minLength", "maxItems", "minItems", "maxContains", "minContains", "maxProperties", "minProperties"} {
+		if v, ok := obj.values[keyword]; ok {
+			if _, ok := AsFloat(v); !ok {
+				return c.errorf(childPath(path, keyword), "%s must be a number", keyword)
+			}
+		}
+	}
+	if v, ok := obj.values["multipleOf"]; ok {
+		if n, _ := AsFloat(v); n <= 0 {
+			return c.errorf(childPath(path, "multipleOf"), "multipleOf must be greater than 0")
+		}
+	}
+	if v, ok := obj.values["required"]; ok {
+		if _, err := stringList(v); err != nil {
+			return c.errorf(childPath(path, "required"), "required %v", err)
+		}
+	}
+	if v, ok := obj.values["enum"]; ok {
+		if _, ok := AsArray(v); !ok {
+			return c.errorf(childPath(path, "enum"), "enum must be an array")
+		}
+	}
+	if v, ok := obj.values["pattern"]; ok {
+		pattern, ok := AsString(v)
+		if !ok {
+			return c.errorf(childPath(path, "pattern"), "pattern must be a string")
+		}
+		return c.compilePattern(childPath(path, "pattern"), pattern)
+	}
+	return nil
+}
+
+func (c *schemaCompiler) compilePattern(path []string, pattern string) error {
+	re, err := regexp.Compile(iregexp(pattern))
+	if err != nil {
+		return c.errorf(path, "%v", err)
+	}
+	c.schema.patterns[FormatPointer(path)] = re
+	return nil
+}
+
+//resolve 把每个$ref解析为schema文档中的位置
+func (c *schemaCompiler) resolve() error {
+	for pointer, ref := range c.refs {
+		target, ok := c.target(ref)
+		if !ok {
+			return &SchemaError{Pointer: pointer + "/$ref", Msg: fmt.Sprintf("cannot resolve %q", ref)}
+		}
+		schema, err := lookup(c.schema.root, target)
+		if err != nil {
+			return &SchemaError{Pointer: pointer + "/$ref", Msg: fmt.Sprintf("cannot resolve %q: %v", ref, err)}
+		}
+		c.schema.refs[pointer] = schemaRef{path: target, schema: schema}
+	}
+	return nil
+}
+
+func (c *schemaCompiler) target(ref string) ([]string, bool) {
+	if path, ok := c.anchors[ref]; ok {
+		return path, true
+	}
+	i := strings.IndexByte(ref, '#')
+	if i < 0 {
+		path, ok := c.resources[ref]
+		return path, ok
+	}
+	path, ok := c.resources[ref[:i]]
+	if !ok {
+		return nil, false
+	}
+	fragment, err := url.PathUnescape(ref[i+1:])
+	if err != nil {
+		return nil, false
+	}
+	tokens, err := ParsePointer(fragment)
+	if err != nil {
+		return nil, false
+	}
+	return append(path[:len(path):len(path)], tokens...), true
+}
+
+//resource 去掉u的片段
+func resource(u *url.URL) string {
+	return strings.SplitN(u.String(), "#", 2)[0]
+}
+
+func childPath(path []string, tokens ...string) []string {
+	return append(path[:len(path):len(path)], tokens...)
+}
+
+func stringList(v Value) ([]string, error) {
+	array, ok := AsArray(v)
+	if !ok {
+		return nil, fmt.Errorf("must be an array")
+	}
+	list := make([]string, len(array.elements))
+	for i, elem := range array.elements {
+		s, ok := AsString(elem)
+		if !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		list[i] = s
+	}
+	return list, nil
+}
+
+//Validate 校验v, 不满足时返回ValidationErrors. v或其中的值为nil时也返回ValidationErrors
+func (s *Schema) Validate(v Value) error {
+	return s.ValidateWithOptions(v, ValidateOptions{})
+}
+
+//ValidateWithOptions 按opts校验v
+func (s *Schema) ValidateWithOptions(v Value, opts ValidateOptions) error {
+	sv := &schemaValidator{schema: s, spans: opts.SourceMap, active: make(map[string]bool)}
+	return sv.validate(v, nil, s.root, nil).Err()
+}
+
+type schemaValidator struct {
+	schema *Schema
+	spans  *SourceMap
+	//active 正在校验的(schema位置, 实例位置), 用来发现没有消耗实例的循环$ref
+	active map[string]bool
+}
+
+func (sv *schemaValidator) errorf(ipath, spath []string, keyword, format string, args ...interface{}) *ValidationError {
+	e := &ValidationError{
+		InstancePath: FormatPointer(ipath),
+		SchemaPath:   FormatPointer(childPath(spath, keyword)),
+		Keyword:      keyword,
+		Msg:          fmt.Sprintf(format, args...),
+	}
+	if sv.spans != nil {
+		if span, ok := sv.spans.Value(e.InstancePath); ok {
+			e.Span = &span
+		}
+	}
+	return e
+}
+
+//valid 判断inst是否满足schema, 不收集错误
+func (sv *schemaValidator) valid(inst Value, ipath []string, schema Value, spath []string) bool {
+	return len(sv.validate(inst, ipath, schema, spath)) == 0
+}
+
+//validate 用spath处的schema校验ipath处的inst
+func (sv *schemaValidator) validate(inst Value, ipath []string, schema Value, spath []string) ValidationErrors {
+	if inst == nil {
+		e := sv.errorf(ipath, spath, "", "value is nil")
+		e.SchemaPath = FormatPointer(spath)
+		return ValidationErrors{e}
+	}
+	if b, ok := schema.(JBool); ok {
+		if !b {
+			e := sv.errorf(ipath, spath, "", "not allowed by false schema")
+			e.SchemaPath, e.Keyword = FormatPointer(spath), "false"
+			return ValidationErrors{e}
+		}
+		return nil
+	}
+	obj, _ := AsObject(schema)
+	var errs ValidationErrors
+	if ref, ok := sv.schema.refs[FormatPointer(spath)]; ok {
+		key := FormatPointer(ref.path) + " " + FormatPointer(ipath)
+		if sv.active[key] {
+			errs = append(errs, sv.errorf(ipath, spath, "$ref", "circular $ref"))
+		} else {
+			sv.active[key] = true
+			errs = append(errs, sv.validate(inst, ipath, ref.schema, ref.path)...)
+			delete(sv.active, key)
+		}
+		if sv.schema.legacy {
+			return errs
+		}
+	}
+	errs = append(errs, sv.generic(inst, ipath, obj, spath)...)
+	if _, ok := AsFloat(inst); ok {
+		errs = append(errs, sv.number(inst, ipath, obj, spath)...)
+	}
+	if s, ok := AsString(inst); ok {
+		errs = append(errs, sv.string(s, ipath, obj, spath)...)
+	}
+	if array, ok := AsArray(inst); ok {
+		errs = append(errs, sv.array(array, ipath, obj, spath)...)
+	}
+	if o, ok := AsObject(inst); ok {
+		errs = append(errs, sv.object(o, ipath, obj, spath)...)
+	}
+	errs = append(errs, sv.combine(inst, ipath, obj, spath)...)
+	return errs
+}
+
+func (sv *schemaValidator) generic(inst Value, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	if t, ok := obj.values["type"]; ok {
+		names := []Value{t}
+		if array, ok := AsArray(t); ok {
+			names = array.elements
+		}
+		matched := false
+		for _, name := range names {
+			s, _ := AsString(name)
+			matched = matched || isSchemaType(inst, s)
+		}
+		if !matched {
+			errs = append(errs, sv.errorf(ipath, spath, "type", "expected %s, got %s", compactJSON(t), schemaTypeOf(inst)))
+		}
+	}
+	if v, ok := obj.values["enum"]; ok {
+		array, _ := AsArray(v)
+		found := false
+		for _, elem := range array.elements {
+			found = found || EqualWithOptions(inst, elem, EqualOptions{NumericEqual: true})
+		}
+		if !found {
+			errs = append(errs, sv.errorf(ipath, spath, "enum", "value must be one of %s", compactJSON(v)))
+		}
+	}
+	if v, ok := obj.values["const"]; ok && !EqualWithOptions(inst, v, EqualOptions{NumericEqual: true}) {
+		errs = append(errs, sv.errorf(ipath, spath, "const", "value must be %s", compactJSON(v)))
+	}
+	return errs
+}
+
+func isSchemaType(v Value, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := AsFloat(v)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "number":
+		_, ok := AsFloat(v)
+		return ok
+	}
+	return schemaTypeOf(v) == name
+}
+
+//schemaTypeOf 返回v在JSON Schema中的类型名, nil当作null
+func schemaTypeOf(v Value) string {
+	if v == nil {
+		return "null"
+	}
+	switch v.Type() {
+	case TypeBool:
+		return "boolean"
+	case TypeNumber:
+		return "number"
+	}
+	return v.Type().String()
+}
+
+func (sv *schemaValidator) number(inst Value, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	if m, ok := obj.values["multipleOf"]; ok && !isMultipleOf(inst, m) {
+		errs = append(errs, sv.errorf(ipath, spath, "multipleOf", "%s is not a multiple of %s", inst, m))
+	}
+	bounds := []struct {
+		keyword string
+		fails   func(int) bool
+		msg     string
+	}{
+		{"maximum", func(c int) bool { return c > 0 }, "greater than"},
+		{"exclusiveMaximum", func(c int) bool { return c >= 0 }, "greater than or equal to"},
+		{"minimum", func(c int) bool { return c < 0 }, "less than"},
+		{"exclusiveMinimum", func(c int) bool { return c <= 0 }, "less than or equal to"},
+	}
+	for _, b := range bounds {
+		if limit, ok := obj.values[b.keyword]; ok && b.fails(Compare(inst, limit)) {
+			errs = append(errs, sv.errorf(ipath, spath, b.keyword, "%s is %s %s", inst, b.msg, limit))
+		}
+	}
+	return errs
+}
+
+func isMultipleOf(v, m Value) bool {
+	a, aok := v.(JNumber)
+	b, bok := m.(JNumber)
+	if aok && bok {
+		return a%b == 0
+	}
+	x, _ := AsFloat(v)
+	y, _ := AsFloat(m)
+	q := x / y
+	if math.IsInf(q, 0) || math.IsNaN(q) {
+		return false
+	}
+	return math.Abs(q-math.Round(q)) <= 1e-9*math.Max(1, math.Abs(q))
+}
+
+//limit 返回数值关键字的值
+func limit(obj *JObject, keyword string) (int, bool) {
+	v, ok := AsFloat(obj.values[keyword])
+	return int(v), ok
+}
+
+func (sv *schemaValidator) string(s string, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	n := utf8.RuneCountInString(s)
+	if max, ok := limit(obj, "maxLength"); ok && n > max {
+		errs = append(errs, sv.errorf(ipath, spath, "maxLength", "length %d is greater than %d", n, max))
+	}
+	if min, ok := limit(obj, "minLength"); ok && n < min {
+		errs = append(errs, sv.errorf(ipath, spath, "minLength", "length %d is less than %d", n, min))
+	}
+	if re, ok := sv.schema.patterns[FormatPointer(childPath(spath, "pattern"))]; ok && !re.MatchString(s) {
+		errs = append(errs, sv.errorf(ipath, spath, "pattern", "%q does not match pattern %q", s, obj.values["pattern"]))
+	}
+	if format, ok := AsString(obj.values["format"]); ok {
+		if check, ok := formats[format]; ok && !check(s) {
+			errs = append(errs, sv.errorf(ipath, spath, "format", "%q is not a valid %s", s, format))
+		}
+	}
+	return errs
+}
+
+func (sv *schemaValidator) array(array *JArray, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	elems := array.elements
+	n := len(elems)
+	if max, ok := limit(obj, "maxItems"); ok && n > max {
+		errs = append(errs, sv.errorf(ipath, spath, "maxItems", "array has %d items, more than %d", n, max))
+	}
+	if min, ok := limit(obj, "minItems"); ok && n < min {
+		errs = append(errs, sv.errorf(ipath, spath, "minItems", "array has %d items, fewer than %d", n, min))
+	}
+	if unique, _ := AsBool(obj.values["uniqueItems"]); unique {
+	outer:
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if EqualWithOptions(elems[i], elems[j], EqualOptions{NumericEqual: true}) {
+					errs = append(errs, sv.errorf(ipath, spath, "uniqueItems", "items %d and %d are equal", i, j))
+					break outer
+				}
+			}
+		}
+	}
+	//prefixItems或数组形式的items校验前面的元素, 之后的元素由items或additionalItems校验
+	prefix, rest := "prefixItems", "items"
+	if _, ok := AsArray(obj.values["items"]); ok {
+		prefix, rest = "items", "additionalItems"
+	}
+	evaluated := 0
+	if tuple, ok := AsArray(obj.values[prefix]); ok {
+		for i, sub := range tuple.elements {
+			if i >= n {
+				break
+			}
+			errs = append(errs, sv.validate(elems[i], indexPath(ipath, i), sub, indexPath(childPath(spath, prefix), i))...)
+			evaluated++
+		}
+	}
+	if sub, ok := obj.values[rest]; ok {
+		for i := evaluated; i < n; i++ {
+			errs = append(errs, sv.validate(elems[i], indexPath(ipath, i), sub, childPath(spath, rest))...)
+		}
+	}
+	if sub, ok := obj.values["contains"]; ok {
+		count := 0
+		for i, elem := range elems {
+			if sv.valid(elem, indexPath(ipath, i), sub, childPath(spath, "contains")) {
+				count++
+			}
+		}
+		min, ok := limit(obj, "minContains")
+		if !ok {
+			min = 1
+		}
+		if count < min {
+			keyword := "contains"
+			if ok {
+				keyword = "minContains"
+			}
+			errs = append(errs, sv.errorf(ipath, spath, keyword, "array contains %d matching items, fewer than %d", count, min))
+		}
+		if max, ok := limit(obj, "maxContains"); ok && count > max {
+			errs = append(errs, sv.errorf(ipath, spath, "maxContains", "array contains %d matching items, more than %d", count, max))
+		}
+	}
+	return errs
+}
+
+func (sv *schemaValidator) object(o *JObject, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	n := o.Len()
+	if max, ok := limit(obj, "maxProperties"); ok && n > max {
+		errs = append(errs, sv.errorf(ipath, spath, "maxProperties", "object has %d properties, more than %d", n, max))
+	}
+	if min, ok := limit(obj, "minProperties"); ok && n < min {
+		errs = append(errs, sv.errorf(ipath, spath, "minProperties", "object has %d properties, fewer than %d", n, min))
+	}
+	if required, err := stringList(obj.values["required"]); err == nil {
+		for _, key := range required {
+			if !o.Has(key) {
+				errs = append(errs, sv.errorf(ipath, spath, "required", "missing required property %q", key))
+			}
+		}
+	}
+	properties, _ := AsObject(obj.values["properties"])
+	patterns, _ := AsObject(obj.values["patternProperties"])
+	additional, hasAdditional := obj.values["additionalProperties"]
+	names, hasNames := obj.values["propertyNames"]
+	for _, key := range o.Keys() {
+		value, path := o.values[key], childPath(ipath, key)
+		if hasNames {
+			errs = append(errs, sv.validate(JString(key), path, names, childPath(spath, "propertyNames"))...)
+		}
+		matched := false
+		if properties != nil {
+			if sub, ok := properties.values[key]; ok {
+				matched = true
+				errs = append(errs, sv.validate(value, path, sub, childPath(spath, "properties", key))...)
+			}
+		}
+		if patterns != nil {
+			for _, pattern := range patterns.Keys() {
+				location := childPath(spath, "patternProperties", pattern)
+				if sv.schema.patterns[FormatPointer(location)].MatchString(key) {
+					matched = true
+					errs = append(errs, sv.validate(value, path, patterns.values[pattern], location)...)
+				}
+			}
+		}
+		if !matched && hasAdditional {
+			if b, ok := additional.(JBool); ok && !bool(b) {
+				errs = append(errs, sv.errorf(path, spath, "additionalProperties", "property %q is not allowed", key))
+				continue
+			}
+			errs = append(errs, sv.validate(value, path, additional, childPath(spath, "additionalProperties"))...)
+		}
+	}
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas", "dependencies"} {
+		deps, ok := AsObject(obj.values[keyword])
+		if !ok {
+			continue
+		}
+		for _, key := range deps.Keys() {
+			if !o.Has(key) {
+				continue
+			}
+			dep := deps.values[key]
+			if required, err := stringList(dep); err == nil {
+				for _, name := range required {
+					if !o.Has(name) {
+						errs = append(errs, sv.errorf(ipath, spath, keyword, "property %q is required by %q", name, key))
+					}
+				}
+				continue
+			}
+			errs = append(errs, sv.validate(o, ipath, dep, childPath(spath, keyword, key))...)
+		}
+	}
+	return errs
+}
+
+func (sv *schemaValidator) combine(inst Value, ipath []string, obj *JObject, spath []string) ValidationErrors {
+	var errs ValidationErrors
+	if all, ok := AsArray(obj.values["allOf"]); ok {
+		for i, sub := range all.elements {
+			errs = append(errs, sv.validate(inst, ipath, sub, indexPath(childPath(spath, "allOf"), i))...)
+		}
+	}
+	if anyOf, ok := AsArray(obj.values["anyOf"]); ok {
+		matched := false
+		for i, sub := range anyOf.elements {
+			if sv.valid(inst, ipath, sub, indexPath(childPath(spath, "anyOf"), i)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			errs = append(errs, sv.errorf(ipath, spath, "anyOf", "value does not match any schema in anyOf"))
+		}
+	}
+	if oneOf, ok := AsArray(obj.values["oneOf"]); ok {
+		count := 0
+		for i, sub := range oneOf.elements {
+			if sv.valid(inst, ipath, sub, indexPath(childPath(spath, "oneOf"), i)) {
+				count++
+			}
+		}
+		if count != 1 {
+			errs = append(errs, sv.errorf(ipath, spath, "oneOf", "value matches %d schemas in oneOf, expected exactly 1", count))
+		}
+	}
+	if not, ok := obj.values["not"]; ok && sv.valid(inst, ipath, not, childPath(spath, "not")) {
+		errs = append(errs, sv.errorf(ipath, spath, "not", "value must not match the schema in not"))
+	}
+	if cond, ok := obj.values["if"]; ok {
+		branch := "else"
+		if sv.valid(inst, ipath, cond, childPath(spath, "if")) {
+			branch = "then"
+		}
+		if sub, ok := obj.values[branch]; ok {
+			errs = append(errs, sv.validate(inst, ipath, sub, childPath(spath, branch))...)
+		}
+	}
+	return errs
+}
+
+//formats format关键字支持的格式, 其他格式不做检查
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+	"time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, "2000-01-01T"+strings.ToUpper(s))
+		return err == nil
+	},
+	"duration": func(s string) bool {
+		return durationPattern.MatchString(s) && s != "P" && !strings.HasSuffix(s, "T")
+	},
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"hostname": isHostname,
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		return strings.Contains(s, ":") && net.ParseIP(s) != nil
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs() && !strings.ContainsAny(s, " \\")
+	},
+	"uri-reference": func(s string) bool {
+		_, err := url.Parse(s)
+		return err == nil && !strings.ContainsAny(s, " \\")
+	},
+	"uuid": uuidPattern.MatchString,
+	"regex": func(s string) bool {
+		_, err := regexp.Compile(iregexp(s))
+		return err == nil
+	},
+	"json-pointer": func(s string) bool {
+		_, err := ParsePointer(s)
+		return err == nil
+	},
+}
+
+var (
+	durationPattern = regexp.MustCompile(`^P(?:\d+W|(?:\d+Y)?(?:\d+M)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+S)?)?)$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	labelPattern    = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+)
+
+//isHostname 按RFC 1123检查主机名
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !labelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+package hjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const serviceSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["name", "port"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "maxLength": 8, "pattern": "^[a-z]+$"},
+    "port": {"$ref": "#/$defs/port"},
+    "replicas": {"type": "integer", "minimum": 1, "multipleOf": 1},
+    "ratio": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1, "multipleOf": 0.1},
+    "mode": {"enum": ["dev", "prod"]},
+    "version": {"const": 2},
+    "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+    "owner": {"type": "string", "format": "email"},
+    "tls": {
+      "type": "object",
+      "if": {"properties": {"enabled": {"const": true}}, "required": ["enabled"]},
+      "then": {"required": ["cert"]},
+      "else": {"maxProperties": 1}
+    },
+    "upstream": {"oneOf": [{"type": "string", "format": "hostname"}, {"type": "string", "format": "ipv4"}]},
+    "timeout": {"anyOf": [{"type": "integer"}, {"type": "string", "format": "duration"}]},
+    "debug": {"not": {"const": true}},
+    "window": {"type": "array", "prefixItems": [{"type": "integer"}, {"type": "integer"}], "items": false},
+    "labels": {"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}
+  },
+  "additionalProperties": false,
+  "$defs": {"port": {"$anchor": "port", "type": "integer", "minimum": 1, "maximum": 65535}}
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(serviceSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := `{"name": "web", "port": 8080, "replicas": 2.0, "ratio": 0.3, "mode": "prod", "version": 2.0,
+		"tags": ["a", "b"], "owner": "ops@example.com", "tls": {"enabled": true, "cert": "x"},
+		"upstream": "backend.local", "timeout": "PT30S", "debug": false, "window": [1, 2],
+		"labels": {"x-team": "core"}}`
+	v, err := ToValue([]byte(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(v); err != nil {
+		t.Fatalf("expect valid, got:%v", err)
+	}
+
+	cases := []struct {
+		input  string
+		expect []string
+	}{
+		{`[]`, []string{"|type|/type"}},
+		{`{"name": "web"}`, []string{"|required|/required"}},
+		{`{"name": "Web1", "port": 0}`, []string{"/name|pattern|/properties/name/pattern", "/port|minimum|/$defs/port/minimum"}},
+		{`{"name": "webserver1", "port": 70000, "extra": 1}`, []string{
+			"/extra|additionalProperties|/additionalProperties",
+			"/name|maxLength|/properties/name/maxLength",
+			"/name|pattern|/properties/name/pattern",
+			"/port|maximum|/$defs/port/maximum"}},
+		{`{"name": "a", "port": 1, "replicas": 1.5, "ratio": 0.25, "mode": "test", "version": 3}`, []string{
+			"/mode|enum|/properties/mode/enum",
+			"/ratio|multipleOf|/properties/ratio/multipleOf",
+			"/replicas|type|/properties/replicas/type",
+			"/replicas|multipleOf|/properties/replicas/multipleOf",
+			"/version|const|/properties/version/const"}},
+		{`{"name": "a", "port": 1, "ratio": 1, "tags": ["a", 1, "a", "b"]}`, []string{
+			"/ratio|exclusiveMaximum|/properties/ratio/exclusiveMaximum",
+			"/tags|maxItems|/properties/tags/maxItems",
+			"/tags|uniqueItems|/properties/tags/uniqueItems",
+			"/tags/1|type|/properties/tags/items/type"}},
+		{`{"name": "a", "port": 1, "owner": "not an email", "tls": {"enabled": true}}`, []string{
+			"/owner|format|/properties/owner/format",
+			"/tls|required|/properties/tls/then/required"}},
+		{`{"name": "a", "port": 1, "tls": {"enabled": false, "cert": "x"}}`, []string{
+			"/tls|maxProperties|/properties/tls/else/maxProperties"}},
+		{`{"name": "a", "port": 1, "upstream": "10.0.0.1", "timeout": "soon", "debug": true}`, []string{
+			"/debug|not|/properties/debug/not",
+			"/timeout|anyOf|/properties/timeout/anyOf",
+			"/upstream|oneOf|/properties/upstream/oneOf"}},
+		{`{"name": "a", "port": 1, "window": [1, "2", 3], "labels": {"x-a": 1, "b": "c"}}`, []string{
+			"/labels/b|additionalProperties|/properties/labels/additionalProperties",
+			"/labels/x-a|type|/properties/labels/patternProperties/^x-/type",
+			"/window/1|type|/properties/window/prefixItems/1/type",
+			"/window/2|false|/properties/window/items"}},
+	}
+	for _, tc := range cases {
+		v, err := ToValue([]byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		var verrs ValidationErrors
+		if err := schema.Validate(v); !errors.As(err, &verrs) {
+			t.Errorf("%s: expect ValidationErrors, got:%v", tc.input, err)
+			continue
+		}
+		for _, e := range verrs {
+			got = append(got, e.InstancePath+"|"+e.Keyword+"|"+e.SchemaPath)
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+			t.Errorf("%s: expect:\n%s\ngot:\n%s", tc.input, strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestSchemaDraft07(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+	  "$schema": "http://json-schema.org/draft-07/schema#",
+	  "definitions": {
+	    "point": {"$id": "#point", "type": "array", "items": [{"type": "number"}, {"type": "number"}], "additionalItems": false}
+	  },
+	  "properties": {
+	    "origin": {"$ref": "#point", "description": "siblings of $ref are ignored", "type": "string"},
+	    "path": {"type": "array", "items": {"$ref": "#/definitions/point"}, "contains": {"const": [0, 0]}}
+	  },
+	  "dependencies": {"origin": ["path"], "path": {"required": ["origin"]}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		input  string
+		expect string
+	}{
+		{`{"origin": [0, 0], "path": [[1, 2], [0, 0]]}`, ""},
+		{`{"origin": [0, 0, 0]}`, "/origin/2|false,|dependencies"},
+		{`{"path": [[1, "2"]]}`, "/path/0/1|type,/path|contains,|required"},
+	}
+	for _, tc := range cases {
+		v, err := ToValue([]byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		if err := schema.Validate(v); err != nil {
+			for _, e := range err.(ValidationErrors) {
+				got = append(got, e.InstancePath+"|"+e.Keyword)
+			}
+		}
+		if strings.Join(got, ",") != tc.expect {
+			t.Errorf("%s: expect %q, got %q", tc.input, tc.expect, strings.Join(got, ","))
+		}
+	}
+}
+
+func TestSchemaRefs(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+	  "$id": "https://example.com/tree.json",
+	  "type": "object",
+	  "properties": {
+	    "value": {"$ref": "#num"},
+	    "children": {"type": "array", "items": {"$ref": "tree.json"}},
+	    "leaf": {"$ref": "https://example.com/leaf.json"}
+	  },
+	  "$defs": {
+	    "num": {"$anchor": "num", "type": "number"},
+	    "leaf": {"$id": "leaf.json", "type": "object", "properties": {"v": {"$ref": "#/properties/w"}, "w": {"type": "string"}}}
+	  }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := ToValue([]byte(`{"value": 1, "children": [{"value": 2, "children": [{"value": "x"}]}], "leaf": {"v": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verrs ValidationErrors
+	if err := schema.Validate(v); !errors.As(err, &verrs) || len(verrs) != 2 ||
+		verrs[0].InstancePath != "/children/0/children/0/value" || verrs[0].SchemaPath != "/$defs/num/type" ||
+		verrs[1].InstancePath != "/leaf/v" || verrs[1].SchemaPath != "/$defs/leaf/properties/w/type" {
+		t.Fatalf("unexpected errors:%v", err)
+	}
+
+	loop, err := ParseSchema([]byte(`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loop.Validate(JNull{}); err == nil || !strings.Contains(err.Error(), "circular $ref") {
+		t.Fatalf("expect circular $ref error, got:%v", err)
+	}
+
+	invalid := []string{
+		`1`, `{"type": "float"}`, `{"minimum": "1"}`, `{"pattern": "("}`, `{"patternProperties": {"[": {}}}`,
+		`{"$ref": "#/$defs/missing"}`, `{"$ref": "https://example.com/other.json"}`, `{"allOf": {}}`,
+		`{"properties": {"a": 1}}`, `{"required": [1]}`, `{"multipleOf": 0}`,
+	}
+	for _, input := range invalid {
+		var schemaErr *SchemaError
+		if _, err := ParseSchema([]byte(input)); !errors.As(err, &schemaErr) {
+			t.Errorf("%s: expect SchemaError, got:%v", input, err)
+		}
+	}
+}
+
+func TestSchemaFormats(t *testing.T) {
+	cases := map[string][]struct {
+		value string
+		valid bool
+	}{
+		"date-time":     {{"2024-02-29T12:30:00Z", true}, {"2024-02-29t12:30:00.5+08:00", true}, {"2024-02-30T12:30:00Z", false}},
+		"date":          {{"2024-02-29", true}, {"2023-02-29", false}},
+		"time":          {{"23:59:59Z", true}, {"24:00:00Z", false}},
+		"duration":      {{"P1DT2H", true}, {"P2W", true}, {"P", false}, {"PT", false}},
+		"email":         {{"a.b@example.com", true}, {"A <a@example.com>", false}},
+		"hostname":      {{"example.com", true}, {"-bad.com", false}},
+		"ipv4":          {{"192.168.0.1", true}, {"256.0.0.1", false}, {"::1", false}},
+		"ipv6":          {{"::1", true}, {"1.2.3.4", false}},
+		"uri":           {{"https://example.com/a?b", true}, {"/relative", false}},
+		"uri-reference": {{"/relative", true}, {"a b", false}},
+		"uuid":          {{"123e4567-e89b-12d3-a456-426614174000", true}, {"123e4567", false}},
+		"regex":         {{"^a+$", true}, {"(", false}},
+		"json-pointer":  {{"/a/~1b", true}, {"a", false}},
+		"unknown":       {{"anything", true}},
+	}
+	for format, values := range cases {
+		schema, err := CompileSchema(&JObject{values: map[string]Value{"format": JString(format)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range values {
+			if err := schema.Validate(JString(tc.value)); (err == nil) != tc.valid {
+				t.Errorf("%s %q: expect valid=%v, got:%v", format, tc.value, tc.valid, err)
+			}
+		}
+	}
+}
+
+func TestSchemaSourcePositions(t *testing.T) {
+	schema, err := ParseSchema([]byte(serviceSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spans SourceMap
+	v, err := ToValueWithOptions([]byte("name: web\nport: 0\n"), Options{Dialect: DialectHjson, SourceMap: &spans})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.ValidateWithOptions(v, ValidateOptions{SourceMap: &spans})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Span == nil || verrs[0].Span.Start != (Position{2, 7, 16}) {
+		t.Fatalf("unexpected errors:%v", err)
+	}
+	if msg := verrs[0].Error(); msg != `line 2 column 7 "/port": 0 is less than 1 (/$defs/port/minimum)` {
+		t.Fatalf("unexpected message:%s", msg)
+	}
+}
+
+func TestSchemaValidateNil(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"type": "array", "items": {"type": ["string", "null"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		value Value
+		path  string
+	}{
+		{nil, ""},
+		{&JArray{elements: []Value{JString("a"), nil}}, "/1"},
+	}
+	for _, tc := range cases {
+		var errs ValidationErrors
+		if !errors.As(schema.Validate(tc.value), &errs) || len(errs) != 1 {
+			t.Fatalf("expect one ValidationError, got:%v", errs)
+		}
+		if errs[0].InstancePath != tc.path || errs[0].Msg != "value is nil" {
+			t.Errorf("expect nil value at %q, got:%v", tc.path, errs[0])
+		}
+	}
+}
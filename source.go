@@ -0,0 +1,142 @@
+package hjson
+
+import (
+	"sort"
+	"strings"
+)
+
+//Span 一个值或键在输入中的范围, End是最后一个字符之后的位置
+type Span struct {
+	Start Position
+	End   Position
+}
+
+//SourceMap 解析时记录的每个值和对象的键在输入中的范围, 以值的JSON Pointer为索引.
+//把它放在Options.SourceMap中启用, 零值可以直接使用. 每次解析一个文档之前会被清空,
+//Decoder读取多个文档时只保留最后一个文档的信息.
+//重复的键按Options.DuplicateKeys记录最终保留的值, DuplicateCollect收集的值
+//以下标区分(如"/a/0"), 收集它们的数组本身没有范围
+type SourceMap struct {
+	values map[string]Span
+	keys   map[string]Span
+	//children 每个位置下记录过的直接子节点, 使删除和移动一棵子树时不必扫描整张表
+	children map[string]map[string]bool
+}
+
+//Value 返回pointer处的值的范围
+func (m *SourceMap) Value(pointer string) (Span, bool) {
+	span, ok := m.values[pointer]
+	return span, ok
+}
+
+//Key 返回pointer处的值在对象中的键的范围, 数组元素和根没有键
+func (m *SourceMap) Key(pointer string) (Span, bool) {
+	span, ok := m.keys[pointer]
+	return span, ok
+}
+
+//At 返回遍历时的路径(如Walk和Query给出的Path)处的值的范围
+func (m *SourceMap) At(path Path) (Span, bool) {
+	return m.Value(path.Pointer())
+}
+
+//Pointers 返回所有记录了范围的值的JSON Pointer, 按字典序排列
+func (m *SourceMap) Pointers() []string {
+	pointers := make([]string, 0, len(m.values))
+	for pointer := range m.values {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+	return pointers
+}
+
+func (m *SourceMap) reset() {
+	m.values = make(map[string]Span)
+	m.keys = make(map[string]Span)
+	m.children = make(map[string]map[string]bool)
+}
+
+func (m *SourceMap) setValue(path []string, span Span) {
+	pointer := FormatPointer(path)
+	m.values[pointer] = span
+	m.link(pointer)
+}
+
+func (m *SourceMap) setKey(path []string, span Span) {
+	pointer := FormatPointer(path)
+	m.keys[pointer] = span
+	m.link(pointer)
+}
+
+//link 在父节点的children中登记pointer
+func (m *SourceMap) link(pointer string) {
+	if pointer == "" {
+		return
+	}
+	parent := pointer[:strings.LastIndexByte(pointer, '/')]
+	set, ok := m.children[parent]
+	if !ok {
+		set = make(map[string]bool)
+		m.children[parent] = set
+	}
+	set[pointer] = true
+}
+
+//subtree 返回pointer及其所有子孙中记录过的位置
+func (m *SourceMap) subtree(pointer string, nodes []string) []string {
+	nodes = append(nodes, pointer)
+	for child := range m.children[pointer] {
+		nodes = m.subtree(child, nodes)
+	}
+	return nodes
+}
+
+//unlink 删除pointer及其所有子孙的记录, 只访问这棵子树, 返回被删除的位置
+func (m *SourceMap) unlink(pointer string) []string {
+	nodes := m.subtree(pointer, nil)
+	for _, p := range nodes {
+		delete(m.children, p)
+	}
+	if pointer != "" {
+		delete(m.children[pointer[:strings.LastIndexByte(pointer, '/')]], pointer)
+	}
+	return nodes
+}
+
+//remove 删除path处的值及其所有子孙的记录
+func (m *SourceMap) remove(path []string) {
+	for _, p := range m.unlink(FormatPointer(path)) {
+		delete(m.values, p)
+		delete(m.keys, p)
+	}
+}
+
+//move 把from处的值及其所有子孙的记录移到to处, from自身的键不变
+func (m *SourceMap) move(from, to []string) {
+	src, dst := FormatPointer(from), FormatPointer(to)
+	key, hasKey := m.keys[src]
+	values := make(map[string]Span)
+	keys := make(map[string]Span)
+	for _, p := range m.unlink(src) {
+		if span, ok := m.values[p]; ok {
+			values[dst+p[len(src):]] = span
+			delete(m.values, p)
+		}
+		if span, ok := m.keys[p]; ok && p != src {
+			keys[dst+p[len(src):]] = span
+		}
+		delete(m.keys, p)
+	}
+	if hasKey {
+		m.keys[src] = key
+		m.link(src)
+	}
+	for p, span := range values {
+		m.values[p] = span
+		m.link(p)
+	}
+	for p, span := range keys {
+		m.keys[p] = span
+		m.link(p)
+	}
+}
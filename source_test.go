@@ -0,0 +1,141 @@
+package hjson
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSourceMap(t *testing.T) {
+	input := "{\n  \"name\": \"web\",\n  \"ports\": [80, {\"tls\": true}]\n}"
+	var spans SourceMap
+	if _, err := ToValueWithOptions([]byte(input), Options{SourceMap: &spans}); err != nil {
+		t.Fatal(err)
+	}
+	text := func(span Span) string {
+		return input[span.Start.Offset:span.End.Offset]
+	}
+	cases := []struct {
+		pointer string
+		expect  string
+		start   Position
+	}{
+		{"", input, Position{1, 1, 0}},
+		{"/name", `"web"`, Position{2, 11, 12}},
+		{"/ports", `[80, {"tls": true}]`, Position{3, 12, 30}},
+		{"/ports/0", `80`, Position{3, 13, 31}},
+		{"/ports/1", `{"tls": true}`, Position{3, 17, 35}},
+		{"/ports/1/tls", `true`, Position{3, 25, 43}},
+	}
+	for _, tc := range cases {
+		span, ok := spans.Value(tc.pointer)
+		if !ok || text(span) != tc.expect || span.Start != tc.start {
+			t.Errorf("%q: expect %q at %v, got %q at %v", tc.pointer, tc.expect, tc.start, text(span), span.Start)
+		}
+	}
+	if span, ok := spans.Key("/ports/1/tls"); !ok || text(span) != `"tls"` {
+		t.Errorf("unexpected key span:%v", span)
+	}
+	if _, ok := spans.Key("/ports/0"); ok {
+		t.Errorf("array elements have no key")
+	}
+	if span, ok := spans.At(Path{"ports", 1}); !ok || span.End != (Position{3, 30, 48}) {
+		t.Errorf("unexpected span:%v", span)
+	}
+	if got := strings.Join(spans.Pointers(), " "); got != " /name /ports /ports/0 /ports/1 /ports/1/tls" {
+		t.Errorf("unexpected pointers:%q", got)
+	}
+
+	hjson := "# config\nname: web server  \nport: 80\n"
+	if _, err := ToValueWithOptions([]byte(hjson), Options{Dialect: DialectHjson, SourceMap: &spans}); err != nil {
+		t.Fatal(err)
+	}
+	if span, ok := spans.Value("/name"); !ok || span.Start != (Position{2, 7, 15}) || hjson[span.Start.Offset:span.End.Offset] != "web server" {
+		t.Errorf("unexpected span:%v", span)
+	}
+	if span, ok := spans.Value("/port"); !ok || hjson[span.Start.Offset:span.End.Offset] != "80" {
+		t.Errorf("unexpected span:%v", span)
+	}
+	if span, ok := spans.Value(""); !ok || span.Start.Line != 2 || span.End != (Position{3, 9, 36}) {
+		t.Errorf("unexpected root span:%v", span)
+	}
+}
+
+func TestSourceMapDuplicates(t *testing.T) {
+	input := `{"a": {"x": 1}, "a": [2], "a": 3}`
+	cases := []struct {
+		mode   DuplicateKeys
+		expect []string
+	}{
+		{DuplicateFirst, []string{"", "/a", "/a/x"}},
+		{DuplicateLast, []string{"", "/a"}},
+		{DuplicateCollect, []string{"", "/a/0", "/a/0/x", "/a/1", "/a/1/0", "/a/2"}},
+	}
+	for _, tc := range cases {
+		var spans SourceMap
+		v, err := ToValueWithOptions([]byte(input), Options{DuplicateKeys: tc.mode, SourceMap: &spans})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pointers := spans.Pointers()
+		if strings.Join(pointers, " ") != strings.Join(tc.expect, " ") {
+			t.Errorf("%d: expect %q, got %q", tc.mode, tc.expect, pointers)
+		}
+		//根对象中有重复的键, 不能单独解析
+		for _, pointer := range pointers[1:] {
+			span, _ := spans.Value(pointer)
+			got, err := Get(v, pointer)
+			if err != nil {
+				t.Fatalf("%d: %s: %v", tc.mode, pointer, err)
+			}
+			if src, _ := ToValue([]byte(input[span.Start.Offset:span.End.Offset])); !Equal(src, got) {
+				t.Errorf("%d: %s: span %v does not match %v", tc.mode, pointer, span, got)
+			}
+		}
+	}
+
+	var spans SourceMap
+	d := NewDecoder(strings.NewReader(`[1] {"b": 2}`), Options{SourceMap: &spans})
+	for {
+		if _, err := d.Decode(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if span, ok := spans.Value("/b"); !ok || span.Start.Offset != 10 {
+		t.Errorf("unexpected span:%v", span)
+	}
+	if _, ok := spans.Value("/0"); ok {
+		t.Errorf("expect spans of the previous document to be cleared")
+	}
+}
+
+//TestSourceMapManyDuplicates 每个重复的键只删除旧值的记录, 不扫描整张表
+func TestSourceMapManyDuplicates(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, `"k%d": {"x": [%d]}, `, i, i)
+	}
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, `"a": {"x": [%d]}, `, i)
+	}
+	b.WriteString(`"end": 1}`)
+	input := b.String()
+	var spans SourceMap
+	if _, err := ToValueWithOptions([]byte(input), Options{DuplicateKeys: DuplicateLast, SourceMap: &spans}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(spans.Pointers()); n != 3*5000+5 {
+		t.Fatalf("unexpected number of spans:%d", n)
+	}
+	span, _ := spans.Value("/a/x/0")
+	if got := input[span.Start.Offset:span.End.Offset]; got != "4999" {
+		t.Fatalf("expect the last duplicate, got %q", got)
+	}
+	if _, ok := spans.Key("/k123/x"); !ok {
+		t.Fatal("expect spans of other keys to be kept")
+	}
+}
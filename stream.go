@@ -0,0 +1,539 @@
+package hjson
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+//Writer和Reader是生成的MarshalHJSON和UnmarshalHJSON使用的流式接口,
+//不经过Value树, 也不使用反射. 见GenerateCodecs
+
+//Marshaler 由GenerateCodecs生成, 把自己直接写入Writer
+type Marshaler interface {
+	MarshalHJSON(w *Writer)
+}
+
+//Unmarshaler 由GenerateCodecs生成, 直接从Reader的记号流中读取自己
+type Unmarshaler interface {
+	UnmarshalHJSON(r *Reader) error
+}
+
+//Marshal 把m编码为紧凑的JSON
+func Marshal(m Marshaler) ([]byte, error) {
+	var w Writer
+	m.MarshalHJSON(&w)
+	return w.Bytes(), w.Err()
+}
+
+//Unmarshal 从严格的JSON中读取u, u之后只允许出现空白
+func Unmarshal(data []byte, u Unmarshaler) error {
+	return UnmarshalWithOptions(data, u, Options{})
+}
+
+//UnmarshalWithOptions 按opts从data中读取u. 不支持Options.Recover和SourceMap
+func UnmarshalWithOptions(data []byte, u Unmarshaler, opts Options) error {
+	r := NewReader(data, opts)
+	if err := u.UnmarshalHJSON(r); err != nil {
+		return err
+	}
+	return r.End()
+}
+
+//Writer 输出紧凑的JSON, 自动在值之间加逗号. 零值可以直接使用.
+//调用者负责保证BeginObject/Key/EndObject等调用的顺序合法
+type Writer struct {
+	buf []byte
+	//comma 下一个值之前需要逗号
+	comma bool
+	err   error
+}
+
+//Bytes 返回已经写入的内容
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+//Err 返回写入过程中的第一个错误, 如Interface遇到不支持的类型
+func (w *Writer) Err() error {
+	return w.err
+}
+
+//Reset 清空内容和错误以便复用缓冲区
+func (w *Writer) Reset() {
+	w.buf, w.comma, w.err = w.buf[:0], false, nil
+}
+
+func (w *Writer) sep() {
+	if w.comma {
+		w.buf = append(w.buf, ',')
+	}
+	w.comma = true
+}
+
+func (w *Writer) BeginObject() {
+	w.sep()
+	w.buf = append(w.buf, '{')
+	w.comma = false
+}
+
+//Key 写入对象的键, 之后必须写入一个值
+func (w *Writer) Key(key string) {
+	w.sep()
+	w.buf = appendJSONString(w.buf, key)
+	w.buf = append(w.buf, ':')
+	w.comma = false
+}
+
+func (w *Writer) EndObject() {
+	w.buf = append(w.buf, '}')
+	w.comma = true
+}
+
+func (w *Writer) BeginArray() {
+	w.sep()
+	w.buf = append(w.buf, '[')
+	w.comma = false
+}
+
+func (w *Writer) EndArray() {
+	w.buf = append(w.buf, ']')
+	w.comma = true
+}
+
+func (w *Writer) String(s string) {
+	w.sep()
+	w.buf = appendJSONString(w.buf, s)
+}
+
+func (w *Writer) Int(n int64) {
+	w.sep()
+	w.buf = strconv.AppendInt(w.buf, n, 10)
+}
+
+func (w *Writer) Uint(n uint64) {
+	w.sep()
+	w.buf = strconv.AppendUint(w.buf, n, 10)
+}
+
+//Float 写入浮点数, bits为32时按float32的精度输出. NaN和无穷大写为null
+func (w *Writer) Float(f float64, bits int) {
+	w.sep()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		w.buf = append(w.buf, "null"...)
+		return
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, 'g', -1, bits)
+}
+
+func (w *Writer) Bool(b bool) {
+	w.sep()
+	w.buf = strconv.AppendBool(w.buf, b)
+}
+
+func (w *Writer) Null() {
+	w.sep()
+	w.buf = append(w.buf, "null"...)
+}
+
+//Value 写入v, 对象的键按字典序排列, nil写为null
+func (w *Writer) Value(v Value) {
+	if obj, ok := AsObject(v); ok {
+		w.BeginObject()
+		for _, key := range obj.Keys() {
+			w.Key(key)
+			w.Value(obj.values[key])
+		}
+		w.EndObject()
+		return
+	}
+	if array, ok := AsArray(v); ok {
+		w.BeginArray()
+		for _, elem := range array.elements {
+			w.Value(elem)
+		}
+		w.EndArray()
+		return
+	}
+	switch v := v.(type) {
+	case JString:
+		w.String(string(v))
+	case JNumber:
+		w.Int(int64(v))
+	case JFloat:
+		w.Float(float64(v), 64)
+	case JBool:
+		w.Bool(bool(v))
+	default:
+		w.Null()
+	}
+}
+
+//Interface 用FromInterface转换x之后写入, 不支持的类型记录在Err中并写为null
+func (w *Writer) Interface(x interface{}) {
+	v, err := FromInterface(x)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.Value(v)
+}
+
+//SortedKeys 返回map的键并排序, 供生成的代码按确定的顺序输出map
+func SortedKeys(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
+
+//appendJSONString 把s编码为JSON字符串追加到dst
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '"' || r == '\\':
+			dst = append(dst, '\\', byte(r))
+		case r == '\n':
+			dst = append(dst, '\\', 'n')
+		case r == '\r':
+			dst = append(dst, '\\', 'r')
+		case r == '\t':
+			dst = append(dst, '\\', 't')
+		case r == utf8.RuneError && size == 1:
+			//与encoding/json相同, 无效的UTF-8字节替换为U+FFFD
+			dst = append(dst, `\ufffd`...)
+		case r < 0x20:
+			dst = append(dst, `\u00`...)
+			dst = append(dst, "0123456789abcdef"[c>>4], "0123456789abcdef"[c&0xf])
+		default:
+			dst = append(dst, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(dst, '"')
+}
+
+//Reader 在记号流上逐个读取值, 语法与解析Value时相同(包括Options中的方言和限制).
+//重复的键按Options.DuplicateKeys处理: DuplicateReject报错, DuplicateFirst时NextKey跳过之后的重复键,
+//DuplicateLast和DuplicateCollect都把重复键交给调用者, 生成的代码中后出现的值覆盖之前的值.
+//出错后所有方法都返回同一个错误
+type Reader struct {
+	p *parser
+	//frames 正在读取的对象和数组
+	frames []readerFrame
+	err    error
+}
+
+type readerFrame struct {
+	//closer 结束记号, tokenEOF表示省略了大括号的根对象
+	closer int
+	//count 已经读过的成员个数, 重复的键也计算在内
+	count int
+	//keys 对象中已经出现的键的位置
+	keys map[string]Position
+}
+
+//NewReader 创建从data读取的Reader
+func NewReader(data []byte, opts Options) *Reader {
+	opts.Recover = false
+	opts.SourceMap = nil
+	p := newParserWithOptions(bytes.NewReader(data), opts)
+	p.start()
+	return &Reader{p: p}
+}
+
+func (r *Reader) fail(err error) error {
+	if r.err == nil {
+		r.err = r.p.getErr(err)
+	}
+	return r.err
+}
+
+func (r *Reader) expect(what string) error {
+	p := r.p
+	if p.token == tokenEOF {
+		return r.fail(p.errorf("%s", errEOF))
+	}
+	return r.fail(p.errorf("expect: %s got:%s", what, p.literal))
+}
+
+//BeginObject 读取'{', 之后用NextKey依次读取键
+func (r *Reader) BeginObject() error {
+	if r.err != nil {
+		return r.err
+	}
+	p := r.p
+	if p.braceless {
+		//Hjson省略了大括号的根对象, start已经把它压入了stack
+		p.braceless = false
+		r.frames = append(r.frames, readerFrame{closer: tokenEOF, keys: make(map[string]Position)})
+		return nil
+	}
+	if p.token != tokenLBrace {
+		return r.expect("'{'")
+	}
+	if err := p.push(tokenLBrace); err != nil {
+		return r.fail(err)
+	}
+	p.match(tokenLBrace)
+	r.frames = append(r.frames, readerFrame{closer: tokenRBrace, keys: make(map[string]Position)})
+	return nil
+}
+
+//NextKey 读取下一个键和之后的':', 对象结束时读取'}'并返回false
+func (r *Reader) NextKey() (string, bool, error) {
+	for r.next() {
+		p := r.p
+		if p.token != tokenString {
+			return "", false, r.expect("key")
+		}
+		key, pos := p.value, p.position()
+		p.match(tokenString)
+		if !p.match(tokenColon) {
+			return "", false, r.expect("':'")
+		}
+		frame := &r.frames[len(r.frames)-1]
+		first, repeated := frame.keys[key]
+		if !repeated {
+			frame.keys[key] = pos
+			return key, true, nil
+		}
+		switch p.opts.DuplicateKeys {
+		case DuplicateReject:
+			dup := &DuplicateKeyError{Key: key, First: first, Second: pos}
+			return "", false, r.fail(&SyntaxError{Msg: dup.Error(), Position: pos, Err: dup})
+		case DuplicateFirst:
+			if err := r.Skip(); err != nil {
+				return "", false, err
+			}
+		default:
+			return key, true, nil
+		}
+	}
+	return "", false, r.err
+}
+
+//BeginArray 读取'[', 之后用NextElement判断是否还有元素
+func (r *Reader) BeginArray() error {
+	if r.err != nil {
+		return r.err
+	}
+	p := r.p
+	if p.token != tokenLBracket {
+		return r.expect("'['")
+	}
+	if err := p.push(tokenLBracket); err != nil {
+		return r.fail(err)
+	}
+	p.match(tokenLBracket)
+	r.frames = append(r.frames, readerFrame{closer: tokenRBracket})
+	return nil
+}
+
+//NextElement 判断数组中是否还有元素, 数组结束时读取']'并返回false
+func (r *Reader) NextElement() (bool, error) {
+	ok := r.next()
+	return ok, r.err
+}
+
+//next 处理成员之间的分隔符, 遇到结束记号时弹出当前的对象或数组
+func (r *Reader) next() bool {
+	if r.err != nil {
+		return false
+	}
+	p := r.p
+	frame := &r.frames[len(r.frames)-1]
+	closer := frame.closer
+	if frame.count > 0 && p.token != closer {
+		switch {
+		case p.match(tokenComma):
+			if err := p.trailingComma(closer); err != nil {
+				r.fail(err)
+				return false
+			}
+		case !p.newlineSeparator():
+			r.expect("',' or " + tokenTable[closer])
+			return false
+		}
+	}
+	if p.token != closer {
+		if p.token == tokenEOF {
+			r.fail(p.errorf("%s", errEOF))
+			return false
+		}
+		if closer == tokenRBrace && p.token == tokenRBracket || closer == tokenRBracket && p.token == tokenRBrace {
+			r.expect(tokenTable[closer])
+			return false
+		}
+		limit, max := "MaxArrayLength", p.opts.Limits.MaxArrayLength
+		if closer != tokenRBracket {
+			limit, max = "MaxObjectMembers", p.opts.Limits.MaxObjectMembers
+		}
+		if max > 0 && frame.count >= max {
+			r.fail(p.limitError(limit, max))
+			return false
+		}
+		frame.count++
+		return true
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	r.frames = r.frames[:len(r.frames)-1]
+	p.match(closer)
+	return false
+}
+
+//Null 当前值为null时读取它并返回true
+func (r *Reader) Null() bool {
+	if r.err == nil && r.p.token == tokenNull {
+		r.p.match(tokenNull)
+		return true
+	}
+	return false
+}
+
+func (r *Reader) String() (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	if r.p.token != tokenString {
+		return "", r.expect("string")
+	}
+	s := r.p.value
+	r.p.match(tokenString)
+	return s, nil
+}
+
+func (r *Reader) Bool() (bool, error) {
+	switch {
+	case r.err != nil:
+		return false, r.err
+	case r.p.match(tokenTrue):
+		return true, nil
+	case r.p.match(tokenFalse):
+		return false, nil
+	}
+	return false, r.expect("true or false")
+}
+
+//number 读取一个数字, 与解析Value时相同. 同时返回数字的位置, 用于报告溢出
+func (r *Reader) number() (Value, Position, error) {
+	pos := r.p.position()
+	if r.err != nil {
+		return nil, pos, r.err
+	}
+	if r.p.token != tokenNumber {
+		return nil, pos, r.expect("number")
+	}
+	v, err := r.p.parseNumber()
+	if err != nil {
+		return nil, pos, r.fail(err)
+	}
+	return v, pos, nil
+}
+
+func (r *Reader) rangeError(v Value, kind string, pos Position) error {
+	return r.fail(&SyntaxError{Msg: "number " + v.String() + " overflows " + kind, Position: pos})
+}
+
+//Int 读取一个整数, 超出bits位有符号整数的范围或者有小数部分时报错
+func (r *Reader) Int(bits int) (int64, error) {
+	v, pos, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(JNumber)
+	if f, isFloat := v.(JFloat); isFloat && float64(f) == math.Trunc(float64(f)) &&
+		float64(f) >= math.MinInt64 && float64(f) < math.MaxInt64 {
+		n, ok = JNumber(f), true
+	}
+	if !ok || bits < 64 && (int64(n) < -1<<uint(bits-1) || int64(n) >= 1<<uint(bits-1)) {
+		return 0, r.rangeError(v, "int"+strconv.Itoa(bits), pos)
+	}
+	return int64(n), nil
+}
+
+//Uint 读取一个非负整数, 超出bits位无符号整数的范围或者有小数部分时报错
+func (r *Reader) Uint(bits int) (uint64, error) {
+	v, pos, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case JNumber:
+		if n >= 0 && (bits == 64 || uint64(n) < 1<<uint(bits)) {
+			return uint64(n), nil
+		}
+	case JFloat:
+		f := float64(n)
+		if f >= 0 && f == math.Trunc(f) && f < math.Ldexp(1, bits) {
+			return uint64(f), nil
+		}
+	}
+	return 0, r.rangeError(v, "uint"+strconv.Itoa(bits), pos)
+}
+
+//Float 读取一个数字, bits为32时检查是否超出float32的范围
+func (r *Reader) Float(bits int) (float64, error) {
+	v, pos, err := r.number()
+	if err != nil {
+		return 0, err
+	}
+	f, _ := AsFloat(v)
+	if bits == 32 && math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+		return 0, r.rangeError(v, "float32", pos)
+	}
+	return f, nil
+}
+
+//Value 读取一个完整的值
+func (r *Reader) Value() (Value, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.p.braceless {
+		r.p.braceless = false
+		obj := NewObject()
+		if err := r.p.parseMembers(obj, tokenEOF); err != nil {
+			return nil, r.fail(err)
+		}
+		return obj, nil
+	}
+	v, err := r.p.parseValues()
+	if err != nil {
+		return nil, r.fail(err)
+	}
+	return v, nil
+}
+
+//Interface 读取一个值并用ToInterface转换
+func (r *Reader) Interface() (interface{}, error) {
+	v, err := r.Value()
+	if err != nil {
+		return nil, err
+	}
+	return ToInterface(v), nil
+}
+
+//Skip 跳过一个值, 用于未知的键
+func (r *Reader) Skip() error {
+	_, err := r.Value()
+	return err
+}
+
+//End 检查根值之后只有空白
+func (r *Reader) End() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.p.token != tokenEOF {
+		return r.fail(r.p.getErr(r.p.errorf("invalid character after top-level value: %s", r.p.literal)))
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package hjson
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var w Writer
+	w.BeginObject()
+	w.Key("s")
+	w.String("a\"b\n\x01é\xff")
+	w.Key("n")
+	w.BeginArray()
+	w.Int(-1)
+	w.Uint(math.MaxUint64)
+	w.Float(0.1, 32)
+	w.Float(math.NaN(), 64)
+	w.EndArray()
+	w.Key("b")
+	w.Bool(true)
+	w.Key("v")
+	w.Value(&JObject{values: map[string]Value{"z": JNull{}, "a": JFloat(1.5)}})
+	w.Key("i")
+	w.Interface(map[string]interface{}{"x": []interface{}{"y"}})
+	w.EndObject()
+	expect := `{"s":"a\"b\n\u0001é\ufffd","n":[-1,18446744073709551615,0.1,null],"b":true,"v":{"a":1.5,"z":null},"i":{"x":["y"]}}`
+	if got := string(w.Bytes()); got != expect || w.Err() != nil {
+		t.Errorf("expect %s, got %s (%v)", expect, got, w.Err())
+	}
+	w.Reset()
+	w.Interface(make(chan int))
+	if w.Err() == nil || string(w.Bytes()) != "null" {
+		t.Errorf("unsupported type should be recorded, got %s", w.Bytes())
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader([]byte(`{"a": [1, null, "x"], "b": {}, "c": true}`), Options{})
+	var got []string
+	if err := r.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		key, more, err := r.NextKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !more {
+			break
+		}
+		got = append(got, key)
+		switch key {
+		case "a":
+			if err := r.BeginArray(); err != nil {
+				t.Fatal(err)
+			}
+			for {
+				more, err := r.NextElement()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !more {
+					break
+				}
+				if r.Null() {
+					got = append(got, "null")
+				} else if err := r.Skip(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		case "c":
+			if b, err := r.Bool(); err != nil || !b {
+				t.Errorf("expect true, got %v %v", b, err)
+			}
+		default:
+			if err := r.Skip(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := r.End(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, " ") != "a null b c" {
+		t.Errorf("unexpected keys:%v", got)
+	}
+
+	//Hjson的根对象可以省略括号, 成员之间可以用换行分隔
+	r = NewReader([]byte("# comment\nname: web\nport: 8080\n"), Options{Dialect: DialectHjson})
+	if err := r.BeginObject(); err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{}
+	for {
+		key, more, err := r.NextKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !more {
+			break
+		}
+		if key == "port" {
+			n, err := r.Int(16)
+			if err != nil || n != 8080 {
+				t.Fatalf("expect 8080, got %v %v", n, err)
+			}
+			continue
+		}
+		values[key], err = r.String()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.End(); err != nil || values["name"] != "web" {
+		t.Errorf("unexpected result:%v %v", values, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	cases := []struct {
+		input string
+		read  func(r *Reader) error
+		msg   string
+	}{
+		{"300", func(r *Reader) error { _, err := r.Int(8); return err }, "overflows int8"},
+		{"-1", func(r *Reader) error { _, err := r.Uint(64); return err }, "overflows uint64"},
+		{"1.5", func(r *Reader) error { _, err := r.Int(64); return err }, "overflows int64"},
+		{"1e39", func(r *Reader) error { _, err := r.Float(32); return err }, "overflows float32"},
+		{`"x"`, func(r *Reader) error { _, err := r.Int(64); return err }, "expect: number"},
+		{"[1 2]", func(r *Reader) error { _, err := r.Value(); return err }, ""},
+		{"1 2", func(r *Reader) error { r.Skip(); return r.End() }, "after top-level value"},
+	}
+	for _, tc := range cases {
+		err := tc.read(NewReader([]byte(tc.input), Options{}))
+		if err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%s: expect error %q, got %v", tc.input, tc.msg, err)
+		}
+	}
+	if n, err := NewReader([]byte("2.0"), Options{}).Int(8); err != nil || n != 2 {
+		t.Errorf("expect 2, got %v %v", n, err)
+	}
+}
+
+func TestReaderLimits(t *testing.T) {
+	//readAll 按键和元素逐个读取, 返回键的序列
+	var readAll func(r *Reader, keys *[]string) error
+	readAll = func(r *Reader, keys *[]string) error {
+		switch r.p.token {
+		case tokenLBrace:
+			if err := r.BeginObject(); err != nil {
+				return err
+			}
+			for {
+				key, ok, err := r.NextKey()
+				if err != nil || !ok {
+					return err
+				}
+				*keys = append(*keys, key)
+				if err := readAll(r, keys); err != nil {
+					return err
+				}
+			}
+		case tokenLBracket:
+			if err := r.BeginArray(); err != nil {
+				return err
+			}
+			for {
+				ok, err := r.NextElement()
+				if err != nil || !ok {
+					return err
+				}
+				if err := readAll(r, keys); err != nil {
+					return err
+				}
+			}
+		}
+		return r.Skip()
+	}
+	cases := []struct {
+		input string
+		opts  Options
+		keys  string
+		msg   string
+	}{
+		{`{"a":1,"b":2}`, Options{Limits: Limits{MaxObjectMembers: 2}}, "a b", ""},
+		{`{"a":1,"b":2,"c":3}`, Options{Limits: Limits{MaxObjectMembers: 2}}, "", "MaxObjectMembers"},
+		{`{"a":1,"a":2,"a":3}`, Options{Limits: Limits{MaxObjectMembers: 2}, DuplicateKeys: DuplicateLast}, "", "MaxObjectMembers"},
+		{`[1,[2,3,4]]`, Options{Limits: Limits{MaxArrayLength: 2}}, "", "MaxArrayLength"},
+		{`{"a":1,"a":2}`, Options{}, "", "repeated key:a"},
+		{`{"a":1,"b":{"c":1},"a":{"d":2},"e":3}`, Options{DuplicateKeys: DuplicateFirst}, "a b c e", ""},
+		{`{"a":1,"a":2}`, Options{DuplicateKeys: DuplicateLast}, "a a", ""},
+	}
+	for _, tc := range cases {
+		var keys []string
+		err := readAll(NewReader([]byte(tc.input), tc.opts), &keys)
+		if tc.msg == "" {
+			if err != nil || strings.Join(keys, " ") != tc.keys {
+				t.Errorf("%s: expect keys %q, got %q %v", tc.input, tc.keys, keys, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%s: expect error %q, got %v", tc.input, tc.msg, err)
+		}
+		if _, err := ToValueWithOptions([]byte(tc.input), tc.opts); err == nil {
+			t.Errorf("%s: expect ToValueWithOptions to fail too", tc.input)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package hjson
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 //visitor pattern
@@ -11,6 +13,7 @@ type Walker interface {
 	walkArray(JArray)
 	walkString(JString)
 	walkNumber(JNumber)
+	walkFloat(JFloat)
 	walkBool(JBool)
 	walkNull(JNull)
 }
@@ -27,6 +30,9 @@ func (s JString) accept(w Walker) {
 func (o JNumber) accept(w Walker) {
 	w.walkNumber(o)
 }
+func (f JFloat) accept(w Walker) {
+	w.walkFloat(f)
+}
 func (o JNull) accept(w Walker) {
 	w.walkNull(o)
 }
@@ -79,9 +85,185 @@ func (n *nodeVisitor) walkString(str JString) {
 func (n *nodeVisitor) walkNumber(number JNumber) {
 	n.buf.WriteString(fmt.Sprint(number))
 }
+func (n *nodeVisitor) walkFloat(f JFloat) {
+	n.buf.WriteString(f.String())
+}
 func (n *nodeVisitor) walkBool(v JBool) {
 	n.buf.WriteString(fmt.Sprintf("%t", v))
 }
 func (n *nodeVisitor) walkNull(v JNull) {
 	n.buf.WriteString(v.String())
 }
+
+//Path 从根到当前节点的路径, 元素是对象的键(string)或者数组的下标(int).
+//传给回调的Path只在回调期间有效, 需要保存时使用Copy
+type Path []interface{}
+
+//Copy 返回p的副本
+func (p Path) Copy() Path {
+	return append(Path{}, p...)
+}
+
+//String 返回RFC 9535的规范化路径, 如$['a'][0]
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range p {
+		switch seg := seg.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(seg) + "]")
+		case string:
+			b.WriteString("['")
+			for _, r := range seg {
+				switch {
+				case r == '\'' || r == '\\':
+					b.WriteString(`\` + string(r))
+				case r == '\b':
+					b.WriteString(`\b`)
+				case r == '\f':
+					b.WriteString(`\f`)
+				case r == '\n':
+					b.WriteString(`\n`)
+				case r == '\r':
+					b.WriteString(`\r`)
+				case r == '\t':
+					b.WriteString(`\t`)
+				case r < 0x20:
+					fmt.Fprintf(&b, `\u%04x`, r)
+				default:
+					b.WriteRune(r)
+				}
+			}
+			b.WriteString("']")
+		}
+	}
+	return b.String()
+}
+
+//Action 回调的返回值, 控制遍历如何继续
+type Action int
+
+const (
+	//Continue 继续遍历, 包括当前节点的子节点
+	Continue Action = iota
+	//Skip 不遍历当前节点的子节点
+	Skip
+	//Stop 立即结束遍历
+	Stop
+	//Delete 只用于Transform, 从父节点中删除当前节点
+	Delete
+)
+
+//Visitor Walk的回调. Enter在访问节点时调用, Leave在节点的子节点都访问完之后调用,
+//Enter返回Skip时Leave仍然会被调用
+type Visitor interface {
+	Enter(path Path, v Value) Action
+	Leave(path Path, v Value) Action
+}
+
+//VisitorFunc 只关心Enter的Visitor
+type VisitorFunc func(path Path, v Value) Action
+
+func (f VisitorFunc) Enter(path Path, v Value) Action {
+	return f(path, v)
+}
+
+func (f VisitorFunc) Leave(Path, Value) Action {
+	return Continue
+}
+
+//Walk 深度优先遍历v, 对象的键按字典序访问. 返回false表示遍历被Stop中止
+func Walk(v Value, visitor Visitor) bool {
+	return walk(nil, v, visitor) != Stop
+}
+
+func walk(path Path, v Value, visitor Visitor) Action {
+	act := visitor.Enter(path, v)
+	if act == Stop {
+		return Stop
+	}
+	if act != Skip {
+		if obj, ok := AsObject(v); ok {
+			for _, key := range obj.Keys() {
+				if walk(append(path, key), obj.values[key], visitor) == Stop {
+					return Stop
+				}
+			}
+		} else if array, ok := AsArray(v); ok {
+			for i, elem := range array.elements {
+				if walk(append(path, i), elem, visitor) == Stop {
+					return Stop
+				}
+			}
+		}
+	}
+	if visitor.Leave(path, v) == Stop {
+		return Stop
+	}
+	return Continue
+}
+
+//TransformFunc Transform的回调, 返回替换当前节点的值以及如何继续.
+//返回Continue时遍历替换之后的值的子节点, 返回Delete时删除当前节点
+type TransformFunc func(path Path, v Value) (Value, Action)
+
+//Transform 深度优先地用fn替换或删除v中的节点, 容器在原地修改.
+//返回新的根节点, 根节点被删除时返回nil
+func Transform(v Value, fn TransformFunc) Value {
+	v, _, _ = transform(nil, v, fn)
+	return v
+}
+
+//transform 返回替换之后的值, 是否删除以及是否中止
+func transform(path Path, v Value, fn TransformFunc) (Value, bool, bool) {
+	v, act := fn(path, v)
+	if v == nil {
+		v = JNull{}
+	}
+	switch act {
+	case Delete:
+		return nil, true, false
+	case Stop:
+		return v, false, true
+	case Skip:
+		return v, false, false
+	}
+	if obj, ok := AsObject(v); ok {
+		for _, key := range obj.Keys() {
+			child, deleted, stop := transform(append(path, key), obj.values[key], fn)
+			if deleted {
+				delete(obj.values, key)
+			} else {
+				obj.values[key] = child
+			}
+			if stop {
+				return obj, false, true
+			}
+		}
+		return obj, false, false
+	}
+	if array, ok := AsArray(v); ok {
+		if _, isValue := v.(JArray); isValue {
+			//JArray值与调用者共享底层数组, 复制之后再原地过滤, 结果以*JArray返回
+			array = &JArray{elements: append([]Value(nil), array.elements...)}
+		}
+		elements := array.elements[:0]
+		for i, elem := range array.elements {
+			child, deleted, stop := transform(append(path, i), elem, fn)
+			if !deleted {
+				elements = append(elements, child)
+			}
+			if stop {
+				elements = append(elements, array.elements[i+1:]...)
+				array.elements = elements
+				return array, false, true
+			}
+		}
+		for i := len(elements); i < len(array.elements); i++ {
+			array.elements[i] = nil
+		}
+		array.elements = elements
+		return array, false, false
+	}
+	return v, false, false
+}
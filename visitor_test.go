@@ -0,0 +1,126 @@
+package hjson
+
+import (
+	"strings"
+	"testing"
+)
+
+type traceVisitor struct {
+	trace []string
+	skip  string
+	stop  string
+}
+
+func (v *traceVisitor) Enter(path Path, value Value) Action {
+	v.trace = append(v.trace, "enter "+path.String())
+	switch path.String() {
+	case v.skip:
+		return Skip
+	case v.stop:
+		return Stop
+	}
+	return Continue
+}
+
+func (v *traceVisitor) Leave(path Path, value Value) Action {
+	v.trace = append(v.trace, "leave "+path.String())
+	return Continue
+}
+
+func TestWalk(t *testing.T) {
+	value, err := ToValue([]byte(`{"b": [1, {"c": 2}], "a": {"it's": true}, "d": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	visitor := &traceVisitor{skip: "$['b'][1]"}
+	if !Walk(value, visitor) {
+		t.Fatal("expect walk to finish")
+	}
+	expect := []string{
+		"enter $",
+		"enter $['a']", `enter $['a']['it\'s']`, `leave $['a']['it\'s']`, "leave $['a']",
+		"enter $['b']", "enter $['b'][0]", "leave $['b'][0]", "enter $['b'][1]", "leave $['b'][1]", "leave $['b']",
+		"enter $['d']", "leave $['d']",
+		"leave $",
+	}
+	if strings.Join(visitor.trace, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected trace:\n%s", strings.Join(visitor.trace, "\n"))
+	}
+
+	visitor = &traceVisitor{stop: "$['b'][0]"}
+	if Walk(value, visitor) {
+		t.Fatal("expect walk to stop")
+	}
+	if last := visitor.trace[len(visitor.trace)-1]; last != "enter $['b'][0]" {
+		t.Fatalf("expect walk to stop at $['b'][0], got:%s", last)
+	}
+
+	var paths []Path
+	Walk(value, VisitorFunc(func(path Path, v Value) Action {
+		if v.Type() == TypeNumber {
+			paths = append(paths, path.Copy())
+		}
+		return Continue
+	}))
+	if len(paths) != 2 || paths[0].String() != "$['b'][0]" || paths[1].String() != "$['b'][1]['c']" {
+		t.Fatalf("unexpected paths:%v", paths)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	value, err := ToValue([]byte(`{"a": [1, null, 2, null, 3], "b": null, "c": {"d": 4}, "e": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//删除所有的null, 数字加倍, 不进入c
+	value = Transform(value, func(path Path, v Value) (Value, Action) {
+		switch v := v.(type) {
+		case JNull:
+			return nil, Delete
+		case JNumber:
+			return v * 2, Continue
+		}
+		if path.String() == "$['c']" {
+			return v, Skip
+		}
+		return v, Continue
+	})
+	expect := &JObject{values: map[string]Value{
+		"a": &JArray{elements: []Value{JNumber(2), JNumber(4), JNumber(6)}},
+		"c": &JObject{values: map[string]Value{"d": JNumber(4)}},
+		"e": JString("x"),
+	}}
+	if err := check(expect, value); err != nil {
+		t.Fatal(err)
+	}
+
+	array := &JArray{elements: []Value{JNumber(1), JNumber(2), JNumber(3)}}
+	Transform(array, func(path Path, v Value) (Value, Action) {
+		if len(path) == 1 && path[0] == 1 {
+			return JString("stop"), Stop
+		}
+		return v, Continue
+	})
+	if err := check(&JArray{elements: []Value{JNumber(1), JString("stop"), JNumber(3)}}, array); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := Transform(JNumber(1), func(Path, Value) (Value, Action) { return nil, Delete }); v != nil {
+		t.Fatalf("expect deleted root, got:%v", v)
+	}
+
+	//JArray值不被修改, 结果以*JArray返回
+	values := JArray{elements: []Value{JNumber(1), JNumber(2)}}
+	got := Transform(values, func(path Path, v Value) (Value, Action) {
+		if len(path) == 1 && path[0] == 0 {
+			return nil, Delete
+		}
+		return v, Continue
+	})
+	if err := check(&JArray{elements: []Value{JNumber(2)}}, got); err != nil {
+		t.Fatal(err)
+	}
+	if values.elements[0] != JNumber(1) || values.elements[1] != JNumber(2) {
+		t.Fatalf("expect JArray value to be unchanged, got:%v", values)
+	}
+}